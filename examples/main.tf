@@ -13,10 +13,31 @@ provider "datahub" {
   client_secret = "50YZbAU@EON1#2L!pmJ5"
 }
 
+resource "datahub_registry_credential" "aybcr" {
+  server   = "aybcr.azurecr.io"
+  username = "datahub-pull"
+  password = "registry token"
+}
+
+resource "datahub_notification_channel" "alerts" {
+  name = "data-team"
+  type = "teams"
+  url  = "https://example.webhook.office.com/webhookb2/placeholder"
+}
+
 resource "datahub_job" "example" {
   name  = "hallo3"
   type  = "full"
   image = "aybcr.azurecr.io/aybi/dh-test-image"
+
+  image_pull_credential_id = datahub_registry_credential.aybcr.credential_id
+
+  notifications = [
+    {
+      channel_id = datahub_notification_channel.alerts.channel_id
+      events     = ["run_failed", "oauth_token_expired"]
+    },
+  ]
   
   environment = {
     "TEST_1" = "UPDATED1",
@@ -27,6 +48,13 @@ resource "datahub_job" "example" {
     "SECRET_1" = "secret sauce",
   }
 
+  secret_refs = {
+    "EXACT_TOKEN" = {
+      source = "vault"
+      path   = "kv/data/exact#token"
+    }
+  }
+
   # oauth= {
   #   application = "exact_online"
   #   flow = "authorization_code"
@@ -68,4 +96,20 @@ resource "datahub_client" "client-test" {
 output "client_secret" {
   value = datahub_client.client-test.client_secret
   sensitive = true
-}
\ No newline at end of file
+}
+resource "datahub_pipeline" "nightly" {
+  name     = "nightly-load"
+  schedule = "0 2 * * *"
+
+  steps = {
+    example = {
+      job_id = datahub_job.example.job_id
+    }
+  }
+}
+
+resource "datahub_job_secret" "api-token" {
+  job_id = datahub_job.example.job_id
+  key    = "API_TOKEN"
+  value  = "rotated token"
+}
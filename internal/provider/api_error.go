@@ -0,0 +1,301 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// maxErrorBodySize bounds how much of an error response is read.
+const maxErrorBodySize = 64 << 10
+
+// requestIDHeaders are the response headers the request ID is read from.
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "Request-Id"}
+
+// apiError is a failed request to the Datahub API. The SDK only returns the
+// error message, the details are read from the response by apiErrorTransport.
+type apiError struct {
+	// StatusCode is the HTTP status of the response, 0 when the request
+	// failed without a response.
+	StatusCode int
+
+	// Code is the error code of the API, like "conflict".
+	Code string
+
+	// Message describes the error.
+	Message string
+
+	// RequestID identifies the request in the logs of the Datahub API.
+	RequestID string
+
+	// FieldErrors are the validation errors of individual fields.
+	FieldErrors []apiFieldError
+
+	// err is the error returned by the SDK.
+	err error
+}
+
+// apiFieldError is a validation error of a field of a request.
+type apiFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	var b strings.Builder
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, "%d %s: ", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	b.WriteString(e.Message)
+	if e.Code != "" {
+		fmt.Fprintf(&b, " (%s)", e.Code)
+	}
+	for _, fieldError := range e.FieldErrors {
+		fmt.Fprintf(&b, "; %s: %s", fieldError.Field, fieldError.Message)
+	}
+	return b.String()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+// apiErrorBody is the JSON error of the Datahub API. Both its own format and
+// RFC 7807 problem details are understood.
+type apiErrorBody struct {
+	Code           string          `json:"code"`
+	Message        string          `json:"message"`
+	Title          string          `json:"title"`
+	Detail         string          `json:"detail"`
+	RequestID      string          `json:"request_id"`
+	RequestIDCamel string          `json:"requestId"`
+	Errors         []apiFieldError `json:"errors"`
+}
+
+// parseAPIError reads the error of a failed response from its body.
+func parseAPIError(statusCode int, header http.Header, body []byte) *apiError {
+	apiErr := &apiError{StatusCode: statusCode}
+	for _, name := range requestIDHeaders {
+		if requestID := header.Get(name); requestID != "" {
+			apiErr.RequestID = requestID
+			break
+		}
+	}
+
+	var errorBody apiErrorBody
+	if err := json.Unmarshal(body, &errorBody); err != nil {
+		// Not JSON, like the error page of a proxy
+		apiErr.Message = strings.TrimSpace(string(body))
+		if len(apiErr.Message) > 500 {
+			apiErr.Message = apiErr.Message[:500] + "..."
+		}
+		return apiErr
+	}
+
+	apiErr.Code = errorBody.Code
+	apiErr.Message = firstNonEmpty(errorBody.Message, errorBody.Detail, errorBody.Title)
+	apiErr.RequestID = firstNonEmpty(apiErr.RequestID, errorBody.RequestID, errorBody.RequestIDCamel)
+	apiErr.FieldErrors = errorBody.Errors
+	return apiErr
+}
+
+// apiErrorRecorder keeps the error of the last response to the Datahub API
+// of an operation, so the error the SDK returns can be matched to it.
+type apiErrorRecorder struct {
+	mu   sync.Mutex
+	last *apiError
+}
+
+type apiErrorRecorderKey struct{}
+
+// withAPIErrorRecorder returns a context recording the API errors of the
+// requests made with it. Every operation that reports errors with
+// apiErrorDiagnostics installs one first, whether or not it is traced.
+func withAPIErrorRecorder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, apiErrorRecorderKey{}, &apiErrorRecorder{})
+}
+
+func (r *apiErrorRecorder) record(apiErr *apiError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last = apiErr
+}
+
+func (r *apiErrorRecorder) take() *apiError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	apiErr := r.last
+	r.last = nil
+	return apiErr
+}
+
+// apiErrorTransport records the error of every failed response in the
+// apiErrorRecorder of the request context. A successful response clears it,
+// so the recorded error always belongs to the last response.
+type apiErrorTransport struct {
+	base http.RoundTripper
+}
+
+func (t *apiErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+
+	recorder, ok := req.Context().Value(apiErrorRecorderKey{}).(*apiErrorRecorder)
+	if !ok {
+		return resp, err
+	}
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		recorder.record(nil)
+		return resp, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+	recorder.record(parseAPIError(resp.StatusCode, resp.Header, body))
+	return resp, nil
+}
+
+// newAPIError returns the typed error of an error returned by the SDK, with
+// the details of the failed response when one was recorded.
+func newAPIError(ctx context.Context, err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	if recorder, ok := ctx.Value(apiErrorRecorderKey{}).(*apiErrorRecorder); ok {
+		if apiErr := recorder.take(); apiErr != nil {
+			apiErr.err = err
+			if apiErr.Message == "" {
+				apiErr.Message = err.Error()
+			}
+			return apiErr
+		}
+	}
+
+	return &apiError{Message: err.Error(), err: err}
+}
+
+// attributeSchema is the schema of a resource or data source, like
+// req.Plan.Schema, used to check that an attribute exists.
+type attributeSchema interface {
+	TypeAtPath(context.Context, path.Path) (attr.Type, diag.Diagnostics)
+}
+
+// apiErrorDiagnostics returns the diagnostics of an error returned by the
+// SDK. Field errors are attached to the attribute of the field when the
+// schema has one, and a conflict with an existing object, like a duplicate
+// name, to conflictPath unless that is empty. The request ID is included for
+// support requests.
+func apiErrorDiagnostics(ctx context.Context, summary string, detail string, err error, conflictPath path.Path, schema attributeSchema) diag.Diagnostics {
+	var diags diag.Diagnostics
+	apiErr := newAPIError(ctx, err)
+
+	var footer strings.Builder
+	if apiErr.StatusCode != 0 {
+		fmt.Fprintf(&footer, "\n\nHTTP status: %d %s", apiErr.StatusCode, http.StatusText(apiErr.StatusCode))
+	}
+	if apiErr.Code != "" {
+		fmt.Fprintf(&footer, "\nError code: %s", apiErr.Code)
+	}
+	if apiErr.RequestID != "" {
+		fmt.Fprintf(&footer, "\nRequest ID: %s (include it when contacting Datahub support)", apiErr.RequestID)
+	}
+	if hint := apiErrorHint(apiErr); hint != "" {
+		footer.WriteString("\n\n" + hint)
+	}
+
+	if len(apiErr.FieldErrors) > 0 {
+		for _, fieldError := range apiErr.FieldErrors {
+			fieldDetail := detail + ": " + fieldError.Message + footer.String()
+			if attributePath, ok := fieldAttributePath(ctx, schema, fieldError.Field); ok {
+				diags.AddAttributeError(attributePath, summary, fieldDetail)
+			} else {
+				diags.AddError(summary, fieldDetail+"\n\nField: "+fieldError.Field)
+			}
+		}
+		return diags
+	}
+
+	message := detail + ": " + apiErr.Message + footer.String()
+	if apiErr.StatusCode == http.StatusConflict && len(conflictPath.Steps()) > 0 {
+		diags.AddAttributeError(conflictPath, summary, message)
+	} else {
+		diags.AddError(summary, message)
+	}
+	return diags
+}
+
+// apiErrorHint suggests what to do about common errors.
+func apiErrorHint(apiErr *apiError) string {
+	switch {
+	case apiErr.StatusCode == http.StatusUnauthorized:
+		return "Check the client_id and client_secret of the provider, or the credentials of the selected profile."
+	case apiErr.StatusCode == http.StatusForbidden:
+		return "The Datahub client of the provider lacks the permission for this operation."
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		return "The Datahub API is throttling the provider, consider setting requests_per_second or max_concurrent_requests."
+	case errors.Is(apiErr.err, context.DeadlineExceeded):
+		return "The request timed out, consider raising request_timeout."
+	default:
+		return ""
+	}
+}
+
+// fieldAttributePath returns the path of the attribute of a field of the API,
+// ok is false when the schema has no such attribute, like for fields the
+// provider doesn't expose.
+func fieldAttributePath(ctx context.Context, schema attributeSchema, field string) (attributePath path.Path, ok bool) {
+	attribute := attributeName(field)
+	if attribute == "" || schema == nil {
+		return path.Empty(), false
+	}
+
+	attributePath = path.Root(attribute)
+	if _, diags := schema.TypeAtPath(ctx, attributePath); diags.HasError() {
+		return path.Empty(), false
+	}
+	return attributePath, true
+}
+
+// attributeName returns the name an attribute of a field of the API would
+// have, like image_pull_credential_id for imagePullCredentialId, and
+// environment for environment.FOO or environment[0]. An empty string is
+// returned when there is no field.
+func attributeName(field string) string {
+	field, _, _ = strings.Cut(field, ".")
+	field, _, _ = strings.Cut(field, "[")
+
+	// An upper case letter starts a word, except within an acronym like ID
+	runes := []rune(field)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			previousLower := !unicode.IsUpper(runes[i-1]) && runes[i-1] != '_'
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || nextLower && unicode.IsUpper(runes[i-1]) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
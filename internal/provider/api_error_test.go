@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testErrorSchema is a schema with attributes API fields map to.
+var testErrorSchema = schema.Schema{
+	Attributes: map[string]schema.Attribute{
+		"name":                     schema.StringAttribute{Required: true},
+		"image_pull_credential_id": schema.StringAttribute{Optional: true},
+		"environment":              schema.MapAttribute{ElementType: types.StringType, Optional: true},
+	},
+}
+
+// diagnosticPath returns the attribute path of the diagnostic, nil when it
+// has none.
+func diagnosticPath(d diag.Diagnostic) *path.Path {
+	withPath, ok := d.(diag.DiagnosticWithPath)
+	if !ok {
+		return nil
+	}
+	p := withPath.Path()
+	return &p
+}
+
+func TestAPIErrorDiagnosticsFieldErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		schema   attributeSchema
+		wantPath *path.Path
+	}{
+		{"attribute", "name", testErrorSchema, pathPointer(path.Root("name"))},
+		{"camel case attribute", "imagePullCredentialId", testErrorSchema, pathPointer(path.Root("image_pull_credential_id"))},
+		{"map element", "environment.FOO", testErrorSchema, pathPointer(path.Root("environment"))},
+		{"field without attribute", "customerCode", testErrorSchema, nil},
+		{"without schema", "name", nil, nil},
+		{"empty field", "", testErrorSchema, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &apiError{
+				StatusCode:  http.StatusUnprocessableEntity,
+				Message:     "validation failed",
+				FieldErrors: []apiFieldError{{Field: tt.field, Message: "is invalid"}},
+			}
+
+			diags := apiErrorDiagnostics(context.Background(), "Error Creating Datahub Job", "Could not create job", err, path.Empty(), tt.schema)
+			if len(diags) != 1 || !diags.HasError() {
+				t.Fatalf("apiErrorDiagnostics() = %v, want one error", diags)
+			}
+
+			got := diagnosticPath(diags[0])
+			switch {
+			case tt.wantPath == nil && got != nil:
+				t.Errorf("diagnostic path = %v, want none", *got)
+			case tt.wantPath != nil && (got == nil || !got.Equal(*tt.wantPath)):
+				t.Errorf("diagnostic path = %v, want %v", got, *tt.wantPath)
+			}
+			if !strings.Contains(diags[0].Detail(), "is invalid") {
+				t.Errorf("diagnostic detail = %q, want the field error", diags[0].Detail())
+			}
+		})
+	}
+}
+
+func TestAPIErrorDiagnosticsConflict(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		conflictPath path.Path
+		wantPath     *path.Path
+	}{
+		{"conflict", http.StatusConflict, path.Root("name"), pathPointer(path.Root("name"))},
+		{"conflict without path", http.StatusConflict, path.Empty(), nil},
+		{"other status", http.StatusBadRequest, path.Root("name"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &apiError{StatusCode: tt.status, Message: "job name already exists", RequestID: "req-1"}
+
+			diags := apiErrorDiagnostics(context.Background(), "Error Creating Datahub Job", "Could not create job", err, tt.conflictPath, testErrorSchema)
+			if len(diags) != 1 {
+				t.Fatalf("apiErrorDiagnostics() = %v, want one error", diags)
+			}
+
+			got := diagnosticPath(diags[0])
+			if (got == nil) != (tt.wantPath == nil) || got != nil && !got.Equal(*tt.wantPath) {
+				t.Errorf("diagnostic path = %v, want %v", got, tt.wantPath)
+			}
+			if !strings.Contains(diags[0].Detail(), "Request ID: req-1") {
+				t.Errorf("diagnostic detail = %q, want the request ID", diags[0].Detail())
+			}
+		})
+	}
+}
+
+func TestAPIErrorDiagnosticsSDKError(t *testing.T) {
+	// Errors without a recorded response, like the run of an init run
+	// failing to complete, are reported with their message
+	diags := apiErrorDiagnostics(context.Background(), "Error creating run", "Could not wait for run", errors.New("run failed"), path.Empty(), testErrorSchema)
+	if len(diags) != 1 || diags[0].Detail() != "Could not wait for run: run failed" {
+		t.Errorf("apiErrorDiagnostics() = %v, want the error message", diags)
+	}
+}
+
+func TestAttributeName(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"name", "name"},
+		{"imagePullCredentialId", "image_pull_credential_id"},
+		{"jobID", "job_id"},
+		{"baseURL", "base_url"},
+		{"environment.FOO", "environment"},
+		{"steps[0]", "steps"},
+		{"client_secret", "client_secret"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			if got := attributeName(tt.field); got != tt.want {
+				t.Errorf("attributeName(%q) = %q, want %q", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func pathPointer(p path.Path) *path.Path {
+	return &p
+}
+
+func TestAPIErrorRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprint(w, `{"code": "validation_failed", "message": "name is taken"}`)
+	}))
+	t.Cleanup(server.Close)
+	client := &http.Client{Transport: &apiErrorTransport{base: http.DefaultTransport}}
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		wantStatus  int
+		wantMessage string
+	}{
+		// The recorder doesn't depend on tracing, startSpan doesn't install it
+		{"with recorder", withAPIErrorRecorder(context.Background()), http.StatusUnprocessableEntity, "name is taken"},
+		{"without recorder", context.Background(), 0, "unexpected status 422"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, span := startSpan(tt.ctx, "datahub_job", "Create")
+			defer span.End()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/jobs", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			apiErr := newAPIError(ctx, errors.New("unexpected status 422"))
+			if apiErr.StatusCode != tt.wantStatus || apiErr.Message != tt.wantMessage {
+				t.Errorf("newAPIError() = %+v, want status %d with %q", apiErr, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// verifyOnConfigureHint is added to the diagnostics of a failed check of
+// verify_on_configure. Turning the check off doesn't fix the cause.
+const verifyOnConfigureHint = "Setting verify_on_configure to false only postpones this error to the first resource or data source that reaches the Datahub API. " +
+	"Turn the check off only when the API can't be reached while configuring, like during a plan without network access."
+
+// callerIdentityPath is the path of the endpoint below base_url that
+// describes the client an access token belongs to, part of the API contract
+// like tokenExchangePath.
+const callerIdentityPath = "/auth/me"
+
+// callerIdentity is the Datahub client the provider is authenticated as.
+type callerIdentity struct {
+	ClientID     string `json:"client_id"`
+	CustomerCode string `json:"customer_code"`
+	CustomerName string `json:"customer_name"`
+
+	// Scopes and TokenExpiresAt describe the current access token of the
+	// provider, they aren't part of the response.
+	Scopes         []string  `json:"-"`
+	TokenExpiresAt time.Time `json:"-"`
+}
+
+// grantSource is a token source of the SDK that also tells the expiry and
+// scopes of its access tokens.
+type grantSource interface {
+	datahub.TokenSource
+	grant(ctx context.Context) (grantedToken, error)
+}
+
+// clientCredentialsTokenSource gets access tokens for a client ID and secret
+// from the Datahub token endpoint, for the SDK and the caller identity.
+type clientCredentialsTokenSource struct {
+	httpClient *http.Client
+
+	// tokenURL is the Datahub token endpoint.
+	tokenURL string
+
+	clientID     string
+	clientSecret string
+
+	mu      sync.Mutex
+	current grantedToken
+}
+
+// Token returns a valid access token, requesting a new one when the cached
+// one is about to expire.
+func (s *clientCredentialsTokenSource) Token(ctx context.Context) (string, error) {
+	token, err := s.grant(ctx)
+	return token.value, err
+}
+
+// grant returns the valid access token with its expiry and scopes.
+func (s *clientCredentialsTokenSource) grant(ctx context.Context) (grantedToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current.fresh() {
+		return s.current, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {s.clientID},
+		"client_secret": {s.clientSecret},
+	}
+	token, err := requestAccessToken(ctx, s.httpClient, s.tokenURL, form)
+	if err != nil {
+		return grantedToken{}, err
+	}
+
+	s.current = token
+	return s.current, nil
+}
+
+// callerIdentityResolver looks up the identity of the credentials of the
+// provider. The identity is looked up once, by verify_on_configure or by the
+// first datahub_caller_identity, and shared afterwards.
+type callerIdentityResolver struct {
+	httpClient *http.Client
+
+	// identityURL is the caller identity endpoint.
+	identityURL string
+
+	// tokenSource gets the access tokens of the provider credentials.
+	tokenSource grantSource
+
+	mu       sync.Mutex
+	identity *callerIdentity
+}
+
+// get returns the identity of the provider credentials, with the scopes and
+// expiry of the access token the SDK currently uses.
+func (r *callerIdentityResolver) get(ctx context.Context) (*callerIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, err := r.tokenSource.grant(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("requesting access token: %w", err)
+	}
+
+	if r.identity == nil {
+		r.identity, err = r.lookup(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	identity := *r.identity
+	identity.Scopes = token.scopes
+	identity.TokenExpiresAt = token.expiresAt
+	return &identity, nil
+}
+
+// lookup reads the identity of the access token from the API.
+func (r *callerIdentityResolver) lookup(ctx context.Context, token grantedToken) (*callerIdentity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.identityURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.value)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading caller identity: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("reading caller identity: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading caller identity: %w", parseAPIError(resp.StatusCode, resp.Header, body))
+	}
+
+	identity := &callerIdentity{}
+	if err := json.Unmarshal(body, identity); err != nil {
+		return nil, fmt.Errorf("decoding caller identity: %w", err)
+	}
+	return identity, nil
+}
+
+// callerIdentityDiagnostics returns the diagnostics of a failed identity
+// lookup, telling the usual causes apart: a base_url that doesn't resolve,
+// a TLS problem, a server that can't be reached, credentials that are
+// rejected, and credentials that lack the permission. A hint, if any, is
+// added to the detail.
+func callerIdentityDiagnostics(err error, hint string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	detail := "\n\nError: " + err.Error()
+	if hint != "" {
+		detail += "\n\n" + hint
+	}
+
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var apiErr *apiError
+	switch {
+	case errors.As(err, &dnsErr):
+		diags.AddAttributeError(
+			path.Root("base_url"),
+			"Unable to Resolve Datahub API Host",
+			"The host "+dnsErr.Name+" cannot be resolved. "+
+				"Check base_url for typos, and that the host can be resolved from the machine running Terraform."+detail,
+		)
+	case isTLSError(err):
+		diags.AddAttributeError(
+			path.Root("base_url"),
+			"Unable to Establish TLS Connection to Datahub API",
+			"The TLS handshake with the Datahub API failed. Check that base_url uses the https scheme with the port of the API, "+
+				"and that no proxy or firewall in between rejects the connection."+detail,
+		)
+	case errors.As(err, &apiErr) && isAuthenticationError(apiErr):
+		diags.AddError(
+			"Datahub Authentication Failed",
+			"The Datahub API rejected the credentials of the provider. Check client_id and client_secret, "+
+				"the credentials of the selected profile, or the OIDC token and the trust of the Datahub client in its issuer."+detail,
+		)
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden:
+		diags.AddError(
+			"Datahub Authorization Failed",
+			"The credentials of the provider are valid, but the Datahub client is not allowed to use the Datahub API. "+
+				"Check the permissions of the client, and that it is not disabled or expired."+detail,
+		)
+	case errors.As(err, &netErr):
+		diags.AddAttributeError(
+			path.Root("base_url"),
+			"Unable to Connect to Datahub API",
+			"The provider cannot reach the Datahub API. Check base_url, proxy_url and the proxy environment variables, "+
+				"and that the Datahub API can be reached from the machine running Terraform."+detail,
+		)
+	default:
+		diags.AddError(
+			"Unable to Verify Datahub Credentials",
+			"An unexpected error occurred while verifying the credentials of the provider."+detail,
+		)
+	}
+	return diags
+}
+
+// isAuthenticationError reports whether the API rejected the credentials,
+// either at the token endpoint or with the access token.
+func isAuthenticationError(apiErr *apiError) bool {
+	switch apiErr.Code {
+	case "invalid_client", "invalid_grant", "unauthorized_client":
+		return true
+	}
+	return apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// isTLSError reports whether err is caused by the TLS handshake, like a
+// server that doesn't speak TLS on the port or aborts the handshake. The
+// certificate of the API isn't verified, see apiTLSConfig, so certificate
+// errors don't occur.
+func isTLSError(err error) bool {
+	// crypto/tls reports alerts, sent or received, as these operations
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && (opErr.Op == "remote error" || opErr.Op == "local error") {
+		return true
+	}
+
+	// net/http replaces the tls.RecordHeaderError of a server answering in
+	// plain HTTP with this message
+	return strings.Contains(err.Error(), "server gave HTTP response to HTTPS client")
+}
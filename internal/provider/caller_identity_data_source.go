@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &callerIdentityDataSource{}
+	_ datasource.DataSourceWithConfigure = &callerIdentityDataSource{}
+)
+
+// NewCallerIdentityDataSource is a helper function to simplify the provider implementation.
+func NewCallerIdentityDataSource() datasource.DataSource {
+	return &callerIdentityDataSource{}
+}
+
+type callerIdentityDataSource struct {
+	callerIdentity *callerIdentityResolver
+}
+
+func (d *callerIdentityDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_caller_identity"
+}
+
+// Schema defines the schema for the data source.
+func (d *callerIdentityDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "The AYBI Datahub Client the provider is authenticated as, with the same credentials the provider resolved. Useful to derive names, or to guard against applying to the wrong tenant.",
+		Attributes: map[string]schema.Attribute{
+			"client_id": schema.StringAttribute{
+				Description: "ID of the client.",
+				Computed:    true,
+			},
+			"customer_code": schema.StringAttribute{
+				Description: "Customer code for the client.",
+				Computed:    true,
+			},
+			"customer_name": schema.StringAttribute{
+				Description: "Name of the client.",
+				Computed:    true,
+			},
+			"scopes": schema.ListAttribute{
+				Description: "Scopes granted to the access token the provider currently uses for its requests.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"token_expires_at": schema.StringAttribute{
+				Description: "Expiry of the access token the provider currently uses for its requests, as an RFC3339 timestamp. The provider requests a new token shortly before it expires.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *callerIdentityDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx, span := startSpan(ctx, "datahub_caller_identity", "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
+	identity, err := d.callerIdentity.get(ctx)
+	if err != nil {
+		resp.Diagnostics.Append(callerIdentityDiagnostics(err, "")...)
+		return
+	}
+
+	state := callerIdentityDataSourceModel{
+		ClientID:       types.StringValue(identity.ClientID),
+		CustomerCode:   types.StringValue(identity.CustomerCode),
+		CustomerName:   types.StringValue(identity.CustomerName),
+		TokenExpiresAt: types.StringValue(identity.TokenExpiresAt.UTC().Format(time.RFC3339)),
+	}
+
+	scopes := identity.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	var diags diag.Diagnostics
+	state.Scopes, diags = types.ListValueFrom(ctx, types.StringType, scopes)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Set state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Configure adds the provider configured caller identity to the data source.
+func (d *callerIdentityDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	d.callerIdentity = req.ProviderData.(*datahubProviderData).callerIdentity
+}
+
+// callerIdentityDataSourceModel maps the data source schema data.
+type callerIdentityDataSourceModel struct {
+	ClientID       types.String `tfsdk:"client_id"`
+	CustomerCode   types.String `tfsdk:"customer_code"`
+	CustomerName   types.String `tfsdk:"customer_name"`
+	Scopes         types.List   `tfsdk:"scopes"`
+	TokenExpiresAt types.String `tfsdk:"token_expires_at"`
+}
@@ -0,0 +1,279 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// testIdentityAPI is a stand-in for the token and caller identity endpoints
+// of the Datahub API.
+type testIdentityAPI struct {
+	// expiresIn is returned as expires_in of the access tokens.
+	expiresIn int
+
+	// identityStatus is the status of the identity endpoint, 200 when 0.
+	identityStatus int
+
+	// tls serves the API over TLS with a self-signed certificate.
+	tls bool
+
+	tokens     atomic.Int32
+	identities atomic.Int32
+}
+
+func (a *testIdentityAPI) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenExchangePath, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parsing token request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "invalid_client"}`)
+			return
+		}
+		count := a.tokens.Add(1)
+		fmt.Fprintf(w, `{"access_token": "access-%d", "expires_in": %d, "scope": "jobs:read jobs:write"}`, count, a.expiresIn)
+	})
+	mux.HandleFunc(callerIdentityPath, func(w http.ResponseWriter, r *http.Request) {
+		a.identities.Add(1)
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer access-") {
+			t.Errorf("identity request Authorization = %q, want an access token", r.Header.Get("Authorization"))
+		}
+		if a.identityStatus != 0 {
+			w.WriteHeader(a.identityStatus)
+			fmt.Fprint(w, `{"code": "forbidden", "message": "client is disabled"}`)
+			return
+		}
+		fmt.Fprint(w, `{"client_id": "app", "customer_code": "ACME", "customer_name": "Acme"}`)
+	})
+
+	server := httptest.NewUnstartedServer(mux)
+	if a.tls {
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestAPIHTTPClient returns a client with the TLS settings of the Datahub
+// API client.
+func newTestAPIHTTPClient() *http.Client {
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: apiTLSConfig()}}
+}
+
+func newTestCallerIdentityResolver(server *httptest.Server, clientSecret string) *callerIdentityResolver {
+	return &callerIdentityResolver{
+		httpClient:  newTestAPIHTTPClient(),
+		identityURL: server.URL + callerIdentityPath,
+		tokenSource: &clientCredentialsTokenSource{
+			httpClient:   newTestAPIHTTPClient(),
+			tokenURL:     server.URL + tokenExchangePath,
+			clientID:     "app",
+			clientSecret: clientSecret,
+		},
+	}
+}
+
+func TestCallerIdentityResolverGet(t *testing.T) {
+	api := &testIdentityAPI{expiresIn: 3600}
+	server := api.serve(t)
+	resolver := newTestCallerIdentityResolver(server, "secret")
+
+	for i := 0; i < 2; i++ {
+		identity, err := resolver.get(context.Background())
+		if err != nil {
+			t.Fatalf("get() error = %v", err)
+		}
+		if identity.ClientID != "app" || identity.CustomerCode != "ACME" || identity.CustomerName != "Acme" {
+			t.Errorf("get() = %+v, want client app of ACME", identity)
+		}
+		if strings.Join(identity.Scopes, " ") != "jobs:read jobs:write" {
+			t.Errorf("Scopes = %v, want the scopes of the token", identity.Scopes)
+		}
+		if until := time.Until(identity.TokenExpiresAt); until < 59*time.Minute || until > time.Hour {
+			t.Errorf("TokenExpiresAt = %v, want the expiry of the token", identity.TokenExpiresAt)
+		}
+	}
+
+	if got := api.tokens.Load(); got != 1 {
+		t.Errorf("token endpoint called %d times, want the token cached", got)
+	}
+	if got := api.identities.Load(); got != 1 {
+		t.Errorf("identity endpoint called %d times, want the identity cached", got)
+	}
+}
+
+func TestCallerIdentityResolverSharesTokenWithSDK(t *testing.T) {
+	api := &testIdentityAPI{expiresIn: 3600}
+	server := api.serve(t)
+	resolver := newTestCallerIdentityResolver(server, "secret")
+
+	// The SDK gets its tokens from the same token source
+	var sdkTokenSource datahub.TokenSource = resolver.tokenSource
+	sdkToken, err := sdkTokenSource.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	if _, err := resolver.get(context.Background()); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if sdkToken != "access-1" || api.tokens.Load() != 1 {
+		t.Errorf("SDK token %q and %d token requests, want the SDK and the identity to share access-1", sdkToken, api.tokens.Load())
+	}
+}
+
+func TestCallerIdentityResolverSelfSignedCertificate(t *testing.T) {
+	// Like every request to the Datahub API, the check doesn't verify the
+	// certificate, so it doesn't fail where the resources would work
+	api := &testIdentityAPI{expiresIn: 3600, tls: true}
+	server := api.serve(t)
+
+	if _, err := newTestCallerIdentityResolver(server, "secret").get(context.Background()); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+}
+
+func TestCallerIdentityResolverRefreshesToken(t *testing.T) {
+	// The token expires within tokenRefreshMargin, so every get refreshes it,
+	// while the identity is still looked up once
+	api := &testIdentityAPI{expiresIn: 1}
+	server := api.serve(t)
+	resolver := newTestCallerIdentityResolver(server, "secret")
+
+	for i := 0; i < 2; i++ {
+		if _, err := resolver.get(context.Background()); err != nil {
+			t.Fatalf("get() error = %v", err)
+		}
+	}
+
+	if got := api.tokens.Load(); got != 2 {
+		t.Errorf("token endpoint called %d times, want 2", got)
+	}
+	if got := api.identities.Load(); got != 1 {
+		t.Errorf("identity endpoint called %d times, want 1", got)
+	}
+}
+
+func TestCallerIdentityDiagnostics(t *testing.T) {
+	tests := []struct {
+		name         string
+		server       func(t *testing.T) string
+		clientSecret string
+		wantSummary  string
+		wantPath     path.Path
+	}{
+		{
+			name: "rejected credentials",
+			server: func(t *testing.T) string {
+				return (&testIdentityAPI{expiresIn: 3600}).serve(t).URL
+			},
+			clientSecret: "wrong",
+			wantSummary:  "Datahub Authentication Failed",
+		},
+		{
+			name: "forbidden",
+			server: func(t *testing.T) string {
+				return (&testIdentityAPI{expiresIn: 3600, identityStatus: http.StatusForbidden}).serve(t).URL
+			},
+			clientSecret: "secret",
+			wantSummary:  "Datahub Authorization Failed",
+		},
+		{
+			name: "TLS handshake",
+			server: func(t *testing.T) string {
+				// Aborts the handshake without a client certificate
+				server := httptest.NewUnstartedServer(http.NotFoundHandler())
+				server.Config.ErrorLog = log.New(io.Discard, "", 0)
+				server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MaxVersion: tls.VersionTLS12}
+				server.StartTLS()
+				t.Cleanup(server.Close)
+				return server.URL
+			},
+			clientSecret: "secret",
+			wantSummary:  "Unable to Establish TLS Connection to Datahub API",
+			wantPath:     path.Root("base_url"),
+		},
+		{
+			name: "plain HTTP on an https base_url",
+			server: func(t *testing.T) string {
+				server := httptest.NewServer(http.NotFoundHandler())
+				t.Cleanup(server.Close)
+				return "https://" + strings.TrimPrefix(server.URL, "http://")
+			},
+			clientSecret: "secret",
+			wantSummary:  "Unable to Establish TLS Connection to Datahub API",
+			wantPath:     path.Root("base_url"),
+		},
+		{
+			name: "unreachable",
+			server: func(t *testing.T) string {
+				server := httptest.NewServer(http.NotFoundHandler())
+				server.Close()
+				return server.URL
+			},
+			clientSecret: "secret",
+			wantPath:     path.Root("base_url"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseURL := tt.server(t)
+			resolver := &callerIdentityResolver{
+				httpClient:  newTestAPIHTTPClient(),
+				identityURL: baseURL + callerIdentityPath,
+				tokenSource: &clientCredentialsTokenSource{
+					httpClient:   newTestAPIHTTPClient(),
+					tokenURL:     baseURL + tokenExchangePath,
+					clientID:     "app",
+					clientSecret: tt.clientSecret,
+				},
+			}
+
+			_, err := resolver.get(context.Background())
+			if err == nil {
+				t.Fatal("get() error = nil, want an error")
+			}
+
+			diags := callerIdentityDiagnostics(err, verifyOnConfigureHint)
+			if len(diags) != 1 {
+				t.Fatalf("callerIdentityDiagnostics() = %v, want one error", diags)
+			}
+			if tt.wantSummary != "" && diags[0].Summary() != tt.wantSummary {
+				t.Errorf("summary = %q, want %q", diags[0].Summary(), tt.wantSummary)
+			}
+
+			withPath, ok := diags[0].(diag.DiagnosticWithPath)
+			switch {
+			case len(tt.wantPath.Steps()) == 0 && ok:
+				t.Errorf("diagnostic path = %v, want none", withPath.Path())
+			case len(tt.wantPath.Steps()) > 0 && (!ok || !withPath.Path().Equal(tt.wantPath)):
+				t.Errorf("diagnostic = %v, want it on %v", diags[0], tt.wantPath)
+			}
+			if !strings.Contains(diags[0].Detail(), "verify_on_configure") {
+				t.Errorf("detail = %q, want the hint", diags[0].Detail())
+			}
+		})
+	}
+}
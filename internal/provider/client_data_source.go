@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"context"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &clientDataSource{}
+	_ datasource.DataSourceWithConfigure = &clientDataSource{}
+)
+
+// NewClientDataSource is a helper function to simplify the provider implementation.
+func NewClientDataSource() datasource.DataSource {
+	return &clientDataSource{}
+}
+
+type clientDataSource struct {
+	client *datahub.DatahubClient
+}
+
+func (d *clientDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_client"
+}
+
+// Schema defines the schema for the data source.
+func (d *clientDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Looks up an AYBI Datahub Client by client_id or customer_code. The client secret is never returned.",
+		Attributes: map[string]schema.Attribute{
+			"client_id": schema.StringAttribute{
+				Description: "ID of the client. Exactly one of client_id or customer_code must be set.",
+				Optional:    true,
+				Computed:    true,
+			},
+			"customer_code": schema.StringAttribute{
+				Description: "Customer code for the client. Exactly one of client_id or customer_code must be set.",
+				Optional:    true,
+				Computed:    true,
+			},
+			"customer_name": schema.StringAttribute{
+				Description: "Name of the client.",
+				Computed:    true,
+			},
+			"expiration_date": schema.StringAttribute{
+				Description: "Expiration date of the client.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *clientDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_client", "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
+	var config clientDataSourceModel
+
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.ClientID.IsNull() == config.CustomerCode.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("client_id"),
+			"Invalid Datahub Client Lookup",
+			"Exactly one of client_id or customer_code must be set to look up a Datahub client.",
+		)
+		return
+	}
+
+	var client *datahub.Client
+	if !config.ClientID.IsNull() {
+		clientID, err := uuid.Parse(config.ClientID.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("client_id"),
+				"Unable to parse client_id",
+				err.Error(),
+			)
+			return
+		}
+
+		client, err = d.client.Auth.GetClient(ctx, clientID)
+		if err != nil {
+			resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+				"Error Reading Datahub Client",
+				"Could not read Datahub client ID "+config.ClientID.ValueString(),
+				err, path.Empty(), req.Config.Schema,
+			)...)
+			return
+		}
+	} else {
+		clients, err := d.client.Auth.ListClients(ctx)
+		if err != nil {
+			resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+				"Error Listing Datahub Clients",
+				"Could not list Datahub clients",
+				err, path.Empty(), req.Config.Schema,
+			)...)
+			return
+		}
+
+		for i := range clients {
+			if clients[i].CustomerCode != config.CustomerCode.ValueString() {
+				continue
+			}
+			if client != nil {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("customer_code"),
+					"Ambiguous Datahub Client Lookup",
+					"More than one Datahub client has customer code "+config.CustomerCode.ValueString()+", use client_id instead.",
+				)
+				return
+			}
+			client = &clients[i]
+		}
+
+		if client == nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("customer_code"),
+				"Datahub Client Not Found",
+				"No Datahub client has customer code "+config.CustomerCode.ValueString()+".",
+			)
+			return
+		}
+	}
+
+	config.ClientID = types.StringValue(client.ClientID)
+	config.CustomerCode = types.StringValue(client.CustomerCode)
+	config.CustomerName = types.StringValue(client.CustomerName)
+	config.ExpirationDate = expirationDateValue(types.StringNull(), client.ExpirationDate)
+
+	// Set state
+	diags = resp.State.Set(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *clientDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	d.client = req.ProviderData.(*datahubProviderData).client
+}
+
+// clientDataSourceModel maps the data source schema data.
+type clientDataSourceModel struct {
+	ClientID       types.String `tfsdk:"client_id"`
+	CustomerCode   types.String `tfsdk:"customer_code"`
+	CustomerName   types.String `tfsdk:"customer_name"`
+	ExpirationDate types.String `tfsdk:"expiration_date"`
+}
@@ -2,25 +2,32 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"time"
 
 	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &clientResource{}
-	_ resource.ResourceWithConfigure   = &clientResource{}
-	_ resource.ResourceWithImportState = &clientResource{}
+	_ resource.Resource                   = &clientResource{}
+	_ resource.ResourceWithConfigure      = &clientResource{}
+	_ resource.ResourceWithImportState    = &clientResource{}
+	_ resource.ResourceWithModifyPlan     = &clientResource{}
+	_ resource.ResourceWithValidateConfig = &clientResource{}
 )
 
 // NewClientResource is a helper function to simplify the provider implementation.
@@ -30,7 +37,8 @@ func NewClientResource() resource.Resource {
 
 // clientResource is the resource implementation.
 type clientResource struct {
-	client *datahub.DatahubClient
+	client            *datahub.DatahubClient
+	expiryWarningDays int64
 }
 
 // Metadata returns the resource type name.
@@ -67,11 +75,29 @@ func (r *clientResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 				},
 			},
 			"expiration_date": schema.StringAttribute{
-				Description: "Expiration date of the client.",
-				Computed:    false,
+				Description: "Expiration date of the client as an RFC3339 timestamp, must be in the future. Conflicts with expires_in.",
+				Computed:    true,
 				Optional:    true,
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.UseStateForUnknown(),
+				Validators: []validator.String{
+					rfc3339Validator{},
+				},
+			},
+			"expires_in": schema.StringAttribute{
+				Description: "Lifetime of the client relative to when it is created or when this value changes, like 720h or 90d. Conflicts with expiration_date.",
+				Optional:    true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"deletion_protection": schema.BoolAttribute{
+				Description: "Prevents the client from being deleted or replaced while true. Must be set to false in a separate apply before the client can be destroyed.",
+				Optional:    true,
+			},
+			"days_until_expiry": schema.Int64Attribute{
+				Description: "Whole days left until expiration_date, negative once the client has expired. Null when the client doesn't expire.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
 				},
 			},
 		},
@@ -80,6 +106,10 @@ func (r *clientResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 
 // Create creates the resource and sets the initial Terraform state.
 func (r *clientResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_client", "Create")
+	defer endSpan(span, &resp.Diagnostics)
+
 	// Retrieve values from plan
 	var client clientResourceModel
 	diags := req.Plan.Get(ctx, &client)
@@ -88,17 +118,28 @@ func (r *clientResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
+	expirationDate, err := plannedExpirationDate(client)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating client",
+			"Could not determine expiration date: "+err.Error(),
+		)
+		return
+	}
+
 	createRequest := datahub.ClientRequest{
-		CustomerCode: client.CustomerCode.ValueString(),
-		CustomerName: client.CustomerName.ValueString(),
+		CustomerCode:   client.CustomerCode.ValueString(),
+		CustomerName:   client.CustomerName.ValueString(),
+		ExpirationDate: expirationDate,
 	}
 
 	createdClient, err := r.client.Auth.CreateClient(ctx, createRequest)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error creating client",
-			"Could not create client, unexpected error: "+err.Error(),
-		)
+			"Could not create client",
+			err, path.Root("customer_code"), req.Plan.Schema,
+		)...)
 		return
 	}
 
@@ -106,11 +147,8 @@ func (r *clientResource) Create(ctx context.Context, req resource.CreateRequest,
 	client.ClientSecret = types.StringValue(createdClient.ClientSecret)
 	client.CustomerCode = types.StringValue(createdClient.CustomerCode)
 	client.CustomerName = types.StringValue(createdClient.CustomerName)
-	if createdClient.ExpirationDate != nil {
-		client.ExpirationDate = types.StringValue(createdClient.ExpirationDate.Format("2006-01-02T15:04:05Z07:00"))
-	} else {
-		client.ExpirationDate = types.StringNull()
-	}
+	client.ExpirationDate = expirationDateValue(client.ExpirationDate, createdClient.ExpirationDate)
+	client.DaysUntilExpiry = daysUntilExpiryValue(createdClient.ExpirationDate)
 
 	diags = resp.State.Set(ctx, client)
 	resp.Diagnostics.Append(diags...)
@@ -121,6 +159,10 @@ func (r *clientResource) Create(ctx context.Context, req resource.CreateRequest,
 
 // Read refreshes the Terraform state with the latest data.
 func (r *clientResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_client", "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
 	// Get current state
 	var state clientResourceModel
 	diags := req.State.Get(ctx, &state)
@@ -140,21 +182,20 @@ func (r *clientResource) Read(ctx context.Context, req resource.ReadRequest, res
 
 	client, err := r.client.Auth.GetClient(ctx, uuidClientID)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error Reading Datahub Client",
-			"Could not read Datahub client ID "+state.ClientID.ValueString()+": "+err.Error(),
-		)
+			"Could not read Datahub client ID "+state.ClientID.ValueString(),
+			err, path.Empty(), req.State.Schema,
+		)...)
 		return
 	}
 
-
 	state.CustomerCode = types.StringValue(client.CustomerCode)
 	state.CustomerName = types.StringValue(client.CustomerName)
-	if client.ExpirationDate != nil {
-		state.ExpirationDate = types.StringValue(client.ExpirationDate.Format("2006-01-02T15:04:05Z07:00"))
-	} else {
-		state.ExpirationDate = types.StringNull()
-	}
+	state.ExpirationDate = expirationDateValue(state.ExpirationDate, client.ExpirationDate)
+	state.DaysUntilExpiry = daysUntilExpiryValue(client.ExpirationDate)
+
+	r.warnExpiry(&resp.Diagnostics, state)
 
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -165,6 +206,10 @@ func (r *clientResource) Read(ctx context.Context, req resource.ReadRequest, res
 
 // Update updates the resource and sets the updated Terraform state on success.
 func (r *clientResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_client", "Update")
+	defer endSpan(span, &resp.Diagnostics)
+
 	// Retrieve values from plan
 	var plan clientResourceModel
 	var state clientResourceModel
@@ -189,35 +234,38 @@ func (r *clientResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	updateRequest := datahub.ClientRequest{
-		CustomerCode:   plan.CustomerCode.ValueString(),
-		CustomerName:           plan.CustomerName.ValueString(),
-		
+	expirationDate, err := plannedExpirationDate(plan)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Updating Datahub Client",
+			"Could not determine expiration date: "+err.Error(),
+		)
+		return
 	}
 
-	if plan.ExpirationDate.IsNull() {
-		updateRequest.ExpirationDate = nil
-	} else {
-		expirationDate, err := time.Parse("2006-01-02T15:04:05Z07:00", plan.ExpirationDate.ValueString())
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error Updating Datahub Client",
-				"Could not parse expiration date "+plan.ExpirationDate.ValueString()+": "+err.Error(),
-			)
-			return
-		}
-		updateRequest.ExpirationDate = &expirationDate
+	updateRequest := datahub.ClientRequest{
+		CustomerCode:   plan.CustomerCode.ValueString(),
+		CustomerName:   plan.CustomerName.ValueString(),
+		ExpirationDate: expirationDate,
 	}
 
-	_, err = r.client.Auth.UpdateClient(ctx, uuidClientID, updateRequest)
+	updatedClient, err := r.client.Auth.UpdateClient(ctx, uuidClientID, updateRequest)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error Updating Datahub Client",
-			"unexpected error: "+err.Error(),
-		)
+			"Could not update Datahub client ID "+state.ClientID.ValueString(),
+			err, path.Root("customer_code"), req.Plan.Schema,
+		)...)
 		return
 	}
 
+	plan.ExpirationDate = expirationDateValue(plan.ExpirationDate, updatedClient.ExpirationDate)
+	// A known value was planned from state and must be kept as is, it is
+	// refreshed by the next Read.
+	if plan.DaysUntilExpiry.IsUnknown() {
+		plan.DaysUntilExpiry = daysUntilExpiryValue(updatedClient.ExpirationDate)
+	}
+
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -227,6 +275,10 @@ func (r *clientResource) Update(ctx context.Context, req resource.UpdateRequest,
 
 // Delete deletes the resource and removes the Terraform state on success.
 func (r *clientResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_client", "Delete")
+	defer endSpan(span, &resp.Diagnostics)
+
 	// Retrieve values from state
 	var state clientResourceModel
 	diags := req.State.Get(ctx, &state)
@@ -235,6 +287,16 @@ func (r *clientResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
+	if state.DeletionProtection.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("deletion_protection"),
+			"Datahub Client Is Protected From Deletion",
+			"The Datahub client for customer "+state.CustomerCode.ValueString()+" ("+state.ClientID.ValueString()+") cannot be deleted because deletion_protection is true. "+
+				"Set deletion_protection to false and apply that change before destroying or replacing the client.",
+		)
+		return
+	}
+
 	// uuidClientID, err := uuid.Parse(state.ClientID.ValueString())
 	// if err != nil {
 	// 	resp.Diagnostics.AddError(
@@ -254,22 +316,219 @@ func (r *clientResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	// }
 }
 
+// ValidateConfig checks that at most one way of setting the expiration is used.
+func (r *clientResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config clientResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.ExpirationDate.IsNull() && !config.ExpiresIn.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("expires_in"),
+			"Conflicting Expiration Attributes",
+			"Only one of expiration_date or expires_in can be set.",
+		)
+	}
+}
+
+// ModifyPlan derives the planned expiration date from expires_in, makes sure
+// a changed expiration date lies in the future and warns when it is near.
+func (r *clientResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do when the resource is being destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan clientResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var config clientResourceModel
+	diags = req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state *clientResourceModel
+	if !req.State.Raw.IsNull() {
+		state = &clientResourceModel{}
+		diags = req.State.Get(ctx, state)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	switch {
+	case !config.ExpirationDate.IsNull():
+		plan.ExpirationDate = config.ExpirationDate
+	case !config.ExpiresIn.IsNull():
+		// expires_in is only applied when the client is created or the value
+		// changes, otherwise every plan would move the expiration date.
+		if state != nil && state.ExpiresIn.Equal(config.ExpiresIn) {
+			plan.ExpirationDate = state.ExpirationDate
+		} else {
+			plan.ExpirationDate = types.StringUnknown()
+		}
+	default:
+		plan.ExpirationDate = types.StringNull()
+	}
+
+	if !plan.ExpirationDate.IsNull() && !plan.ExpirationDate.IsUnknown() && (state == nil || !sameExpirationDate(state.ExpirationDate, plan.ExpirationDate)) {
+		expirationDate, err := time.Parse(time.RFC3339, plan.ExpirationDate.ValueString())
+		if err == nil && !expirationDate.After(time.Now()) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("expiration_date"),
+				"Expiration Date In The Past",
+				"The expiration_date "+plan.ExpirationDate.ValueString()+" must be in the future.",
+			)
+			return
+		}
+	}
+
+	if state == nil || !sameExpirationDate(state.ExpirationDate, plan.ExpirationDate) {
+		plan.DaysUntilExpiry = types.Int64Unknown()
+	}
+
+	r.warnExpiry(&resp.Diagnostics, plan)
+
+	diags = resp.Plan.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
 func (r *clientResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
 
-	r.client = req.ProviderData.(*datahub.DatahubClient)
+	providerData := req.ProviderData.(*datahubProviderData)
+	r.client = providerData.client
+	r.expiryWarningDays = providerData.clientExpiryWarningDays
+}
+
+// warnExpiry adds a warning when the client expires within the configured
+// client_expiry_warning_days.
+func (r *clientResource) warnExpiry(diags *diag.Diagnostics, client clientResourceModel) {
+	if r.expiryWarningDays <= 0 || client.ExpirationDate.IsNull() || client.ExpirationDate.IsUnknown() {
+		return
+	}
+
+	expirationDate, err := time.Parse(time.RFC3339, client.ExpirationDate.ValueString())
+	if err != nil {
+		return
+	}
+
+	days := daysUntil(expirationDate)
+	if days > r.expiryWarningDays {
+		return
+	}
+
+	if days < 0 {
+		diags.AddAttributeWarning(
+			path.Root("expiration_date"),
+			"Datahub Client Expired",
+			fmt.Sprintf("The Datahub client for customer %s expired on %s. Integrations using it can no longer authenticate.",
+				client.CustomerCode.ValueString(), client.ExpirationDate.ValueString()),
+		)
+		return
+	}
+
+	diags.AddAttributeWarning(
+		path.Root("expiration_date"),
+		"Datahub Client Expiring Soon",
+		fmt.Sprintf("The Datahub client for customer %s expires on %s, in %d day(s). Extend expiration_date or expires_in before integrations start failing.",
+			client.CustomerCode.ValueString(), client.ExpirationDate.ValueString(), days),
+	)
 }
 
 func (r *clientResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_client", "ImportState")
+	defer endSpan(span, &resp.Diagnostics)
+
 	resource.ImportStatePassthroughID(ctx, path.Root("client_id"), req, resp)
 }
 
 type clientResourceModel struct {
-	CustomerCode   types.String `tfsdk:"customer_code"`
-	CustomerName   types.String `tfsdk:"customer_name"`
-	ClientID       types.String `tfsdk:"client_id"`
-	ClientSecret   types.String `tfsdk:"client_secret"`
-	ExpirationDate types.String `tfsdk:"expiration_date"`
+	CustomerCode       types.String `tfsdk:"customer_code"`
+	CustomerName       types.String `tfsdk:"customer_name"`
+	ClientID           types.String `tfsdk:"client_id"`
+	ClientSecret       types.String `tfsdk:"client_secret"`
+	ExpirationDate     types.String `tfsdk:"expiration_date"`
+	ExpiresIn          types.String `tfsdk:"expires_in"`
+	DeletionProtection types.Bool   `tfsdk:"deletion_protection"`
+	DaysUntilExpiry    types.Int64  `tfsdk:"days_until_expiry"`
+}
+
+// plannedExpirationDate returns the expiration date to send to the API, either
+// the configured expiration_date or now plus expires_in.
+func plannedExpirationDate(plan clientResourceModel) (*time.Time, error) {
+	if !plan.ExpirationDate.IsNull() && !plan.ExpirationDate.IsUnknown() {
+		expirationDate, err := time.Parse(time.RFC3339, plan.ExpirationDate.ValueString())
+		if err != nil {
+			return nil, err
+		}
+		return &expirationDate, nil
+	}
+
+	if !plan.ExpiresIn.IsNull() {
+		expiresIn, err := parseDuration(plan.ExpiresIn.ValueString())
+		if err != nil {
+			return nil, err
+		}
+		expirationDate := time.Now().Add(expiresIn).UTC().Truncate(time.Second)
+		return &expirationDate, nil
+	}
+
+	return nil, nil
+}
+
+// expirationDateValue converts an expiration date returned by the API to its
+// Terraform value. When current holds the same instant, possibly in another
+// time zone, it is kept so the API's normalisation doesn't show up as a diff.
+func expirationDateValue(current types.String, expirationDate *time.Time) types.String {
+	if expirationDate == nil {
+		return types.StringNull()
+	}
+
+	value := types.StringValue(expirationDate.Format(time.RFC3339))
+	if sameExpirationDate(current, value) {
+		return current
+	}
+	return value
+}
+
+// daysUntilExpiryValue returns the days_until_expiry value for an expiration
+// date returned by the API.
+func daysUntilExpiryValue(expirationDate *time.Time) types.Int64 {
+	if expirationDate == nil {
+		return types.Int64Null()
+	}
+	return types.Int64Value(daysUntil(*expirationDate))
+}
+
+// daysUntil returns the number of whole days until t, rounded down.
+func daysUntil(t time.Time) int64 {
+	return int64(math.Floor(time.Until(t).Hours() / 24))
+}
+
+// sameExpirationDate reports whether both values hold the same instant.
+func sameExpirationDate(a, b types.String) bool {
+	if a.IsNull() || a.IsUnknown() || b.IsNull() || b.IsUnknown() {
+		return a.Equal(b)
+	}
+
+	timeA, errA := time.Parse(time.RFC3339, a.ValueString())
+	timeB, errB := time.Parse(time.RFC3339, b.ValueString())
+	if errA != nil || errB != nil {
+		return a.Equal(b)
+	}
+	return timeA.Equal(timeB)
 }
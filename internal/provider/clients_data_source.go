@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"context"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &clientsDataSource{}
+	_ datasource.DataSourceWithConfigure = &clientsDataSource{}
+)
+
+// NewClientsDataSource is a helper function to simplify the provider implementation.
+func NewClientsDataSource() datasource.DataSource {
+	return &clientsDataSource{}
+}
+
+type clientsDataSource struct {
+	client *datahub.DatahubClient
+}
+
+func (d *clientsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_clients"
+}
+
+// Schema defines the schema for the data source.
+func (d *clientsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists all AYBI Datahub Clients. Client secrets are never returned.",
+		Attributes: map[string]schema.Attribute{
+			"clients": schema.ListNestedAttribute{
+				Description: "All clients visible to the provider credentials.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"client_id": schema.StringAttribute{
+							Description: "ID of the client.",
+							Computed:    true,
+						},
+						"customer_code": schema.StringAttribute{
+							Description: "Customer code for the client.",
+							Computed:    true,
+						},
+						"customer_name": schema.StringAttribute{
+							Description: "Name of the client.",
+							Computed:    true,
+						},
+						"expiration_date": schema.StringAttribute{
+							Description: "Expiration date of the client.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *clientsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_clients", "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
+	var state clientsDataSourceModel
+
+	clients, err := d.client.Auth.ListClients(ctx)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Listing Datahub Clients",
+			"Could not list Datahub clients",
+			err, path.Empty(), req.Config.Schema,
+		)...)
+		return
+	}
+
+	state.Clients = []clientDataSourceModel{}
+	for _, client := range clients {
+		clientState := clientDataSourceModel{
+			ClientID:       types.StringValue(client.ClientID),
+			CustomerCode:   types.StringValue(client.CustomerCode),
+			CustomerName:   types.StringValue(client.CustomerName),
+			ExpirationDate: expirationDateValue(types.StringNull(), client.ExpirationDate),
+		}
+
+		state.Clients = append(state.Clients, clientState)
+	}
+
+	// Set state
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *clientsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	d.client = req.ProviderData.(*datahubProviderData).client
+}
+
+// clientsDataSourceModel maps the data source schema data.
+type clientsDataSourceModel struct {
+	Clients []clientDataSourceModel `tfsdk:"clients"`
+}
@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"context"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// containerResourcesAttributes returns the attributes of the resources block
+// shared by datahub_job and datahub_init_run.
+func containerResourcesAttributes() map[string]schema.Attribute {
+	quantity := func(description string) schema.StringAttribute {
+		return schema.StringAttribute{
+			Description: description,
+			Optional:    true,
+			Validators: []validator.String{
+				quantityValidator{},
+			},
+		}
+	}
+
+	return map[string]schema.Attribute{
+		"cpu_request":               quantity("CPU guaranteed to the container, like 500m or 2"),
+		"cpu_limit":                 quantity("maximum CPU the container may use, like 1 or 1500m"),
+		"memory_request":            quantity("memory guaranteed to the container, like 512Mi"),
+		"memory_limit":              quantity("maximum memory the container may use before it is OOM-killed, like 2Gi"),
+		"ephemeral_storage_request": quantity("local scratch storage guaranteed to the container, like 1Gi"),
+		"ephemeral_storage_limit":   quantity("maximum local scratch storage the container may use, like 10Gi"),
+		"max_duration": schema.StringAttribute{
+			Description: "maximum runtime of a run before it is stopped, like 30m or 2h",
+			Optional:    true,
+			Validators: []validator.String{
+				durationValidator{},
+			},
+		},
+	}
+}
+
+type containerResourcesModel struct {
+	CPURequest              types.String `tfsdk:"cpu_request"`
+	CPULimit                types.String `tfsdk:"cpu_limit"`
+	MemoryRequest           types.String `tfsdk:"memory_request"`
+	MemoryLimit             types.String `tfsdk:"memory_limit"`
+	EphemeralStorageRequest types.String `tfsdk:"ephemeral_storage_request"`
+	EphemeralStorageLimit   types.String `tfsdk:"ephemeral_storage_limit"`
+	MaxDuration             types.String `tfsdk:"max_duration"`
+}
+
+// Equal reports whether both models hold the same values.
+func (m *containerResourcesModel) Equal(other *containerResourcesModel) bool {
+	if m == nil || other == nil {
+		return m == other
+	}
+
+	return m.CPURequest.Equal(other.CPURequest) &&
+		m.CPULimit.Equal(other.CPULimit) &&
+		m.MemoryRequest.Equal(other.MemoryRequest) &&
+		m.MemoryLimit.Equal(other.MemoryLimit) &&
+		m.EphemeralStorageRequest.Equal(other.EphemeralStorageRequest) &&
+		m.EphemeralStorageLimit.Equal(other.EphemeralStorageLimit) &&
+		m.MaxDuration.Equal(other.MaxDuration)
+}
+
+// toAPI converts the resources block to its API representation. A nil block
+// results in an empty config, which resets the job to the engine defaults.
+func (m *containerResourcesModel) toAPI() (*datahub.ResourcesConfig, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if m == nil {
+		return &datahub.ResourcesConfig{}, diags
+	}
+
+	config := &datahub.ResourcesConfig{
+		CPURequest:              m.CPURequest.ValueString(),
+		CPULimit:                m.CPULimit.ValueString(),
+		MemoryRequest:           m.MemoryRequest.ValueString(),
+		MemoryLimit:             m.MemoryLimit.ValueString(),
+		EphemeralStorageRequest: m.EphemeralStorageRequest.ValueString(),
+		EphemeralStorageLimit:   m.EphemeralStorageLimit.ValueString(),
+	}
+
+	if !m.MaxDuration.IsNull() {
+		maxDuration, err := parseDuration(m.MaxDuration.ValueString())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("resources").AtName("max_duration"),
+				"Invalid Duration",
+				"Could not parse max_duration "+m.MaxDuration.ValueString()+": "+err.Error(),
+			)
+			return nil, diags
+		}
+		config.MaxDuration = maxDuration
+	}
+
+	return config, diags
+}
+
+// containerResourcesFromAPI converts the resources returned by the API to the
+// resources block. Values that are equal to the ones in state but formatted
+// differently, like 0.5 and 500m, keep their state representation.
+func containerResourcesFromAPI(state *containerResourcesModel, config *datahub.ResourcesConfig) *containerResourcesModel {
+	if config == nil || *config == (datahub.ResourcesConfig{}) {
+		return nil
+	}
+
+	if state == nil {
+		state = &containerResourcesModel{}
+	}
+
+	resources := &containerResourcesModel{
+		CPURequest:              quantityValue(state.CPURequest, config.CPURequest),
+		CPULimit:                quantityValue(state.CPULimit, config.CPULimit),
+		MemoryRequest:           quantityValue(state.MemoryRequest, config.MemoryRequest),
+		MemoryLimit:             quantityValue(state.MemoryLimit, config.MemoryLimit),
+		EphemeralStorageRequest: quantityValue(state.EphemeralStorageRequest, config.EphemeralStorageRequest),
+		EphemeralStorageLimit:   quantityValue(state.EphemeralStorageLimit, config.EphemeralStorageLimit),
+		MaxDuration:             types.StringNull(),
+	}
+
+	if config.MaxDuration > 0 {
+		resources.MaxDuration = types.StringValue(config.MaxDuration.String())
+		if current, err := parseDuration(state.MaxDuration.ValueString()); err == nil && current == config.MaxDuration {
+			resources.MaxDuration = state.MaxDuration
+		}
+	}
+
+	return resources
+}
+
+// quantityValue returns the Terraform value for a quantity returned by the
+// API, keeping current when it holds the same quantity.
+func quantityValue(current types.String, quantity string) types.String {
+	if quantity == "" {
+		return types.StringNull()
+	}
+
+	currentValue, errCurrent := parseQuantity(current.ValueString())
+	newValue, errNew := parseQuantity(quantity)
+	if errCurrent == nil && errNew == nil && currentValue == newValue {
+		return current
+	}
+	return types.StringValue(quantity)
+}
+
+// validateContainerResources checks that no request in the resources block
+// exceeds its limit.
+func validateContainerResources(ctx context.Context, config tfsdk.Config, diags *diag.Diagnostics) {
+	pairs := [][2]string{
+		{"cpu_request", "cpu_limit"},
+		{"memory_request", "memory_limit"},
+		{"ephemeral_storage_request", "ephemeral_storage_limit"},
+	}
+
+	for _, pair := range pairs {
+		var request, limit types.String
+		diags.Append(config.GetAttribute(ctx, path.Root("resources").AtName(pair[0]), &request)...)
+		diags.Append(config.GetAttribute(ctx, path.Root("resources").AtName(pair[1]), &limit)...)
+		if diags.HasError() {
+			return
+		}
+
+		if request.IsNull() || request.IsUnknown() || limit.IsNull() || limit.IsUnknown() {
+			continue
+		}
+
+		requestValue, errRequest := parseQuantity(request.ValueString())
+		limitValue, errLimit := parseQuantity(limit.ValueString())
+		if errRequest == nil && errLimit == nil && requestValue > limitValue {
+			diags.AddAttributeError(
+				path.Root("resources").AtName(pair[0]),
+				"Resource Request Exceeds Limit",
+				"The "+pair[0]+" "+request.ValueString()+" is larger than the "+pair[1]+" "+limit.ValueString()+".",
+			)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testNestedConfig returns the config of a schema with the single nested
+// attribute name, holding the given values and null otherwise.
+func testNestedConfig(t *testing.T, name string, attributes map[string]schema.Attribute, values map[string]tftypes.Value) tfsdk.Config {
+	t.Helper()
+
+	ctx := context.Background()
+	s := schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			name: schema.SingleNestedAttribute{Optional: true, Attributes: attributes},
+		},
+	}
+
+	nestedType := s.Attributes[name].GetType().TerraformType(ctx).(tftypes.Object)
+	nested := map[string]tftypes.Value{}
+	for key, typ := range nestedType.AttributeTypes {
+		nested[key] = tftypes.NewValue(typ, nil)
+		if value, ok := values[key]; ok {
+			nested[key] = value
+		}
+	}
+
+	return tfsdk.Config{
+		Schema: s,
+		Raw: tftypes.NewValue(s.Type().TerraformType(ctx), map[string]tftypes.Value{
+			name: tftypes.NewValue(nestedType, nested),
+		}),
+	}
+}
+
+func TestValidateContainerResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]string
+		wantPath *path.Path
+	}{
+		{"request below limit", map[string]string{"cpu_request": "500m", "cpu_limit": "1"}, nil},
+		{"request equal to limit in other notation", map[string]string{"cpu_request": "0.5", "cpu_limit": "500m"}, nil},
+		{"binary and decimal suffixes", map[string]string{"memory_request": "1G", "memory_limit": "1Gi"}, nil},
+		{"request without limit", map[string]string{"memory_request": "64Gi"}, nil},
+		{"cpu request above limit", map[string]string{"cpu_request": "2", "cpu_limit": "1500m"}, pathPointer(path.Root("resources").AtName("cpu_request"))},
+		{"memory request above limit", map[string]string{"memory_request": "1Gi", "memory_limit": "1G"}, pathPointer(path.Root("resources").AtName("memory_request"))},
+		{"ephemeral storage request above limit", map[string]string{"ephemeral_storage_request": "10Gi", "ephemeral_storage_limit": "1Gi"}, pathPointer(path.Root("resources").AtName("ephemeral_storage_request"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]tftypes.Value{}
+			for key, value := range tt.values {
+				values[key] = tftypes.NewValue(tftypes.String, value)
+			}
+			config := testNestedConfig(t, "resources", containerResourcesAttributes(), values)
+
+			var diags diag.Diagnostics
+			validateContainerResources(context.Background(), config, &diags)
+
+			if tt.wantPath == nil {
+				if diags.HasError() {
+					t.Errorf("validateContainerResources() = %v, want no error", diags)
+				}
+				return
+			}
+			if len(diags) != 1 {
+				t.Fatalf("validateContainerResources() = %v, want one error", diags)
+			}
+			if got := diagnosticPath(diags[0]); got == nil || !got.Equal(*tt.wantPath) {
+				t.Errorf("diagnostic path = %v, want %v", got, *tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestValidateContainerResourcesUnknown(t *testing.T) {
+	// Values known only at apply time are checked by the API
+	config := testNestedConfig(t, "resources", containerResourcesAttributes(), map[string]tftypes.Value{
+		"cpu_request": tftypes.NewValue(tftypes.String, "4"),
+		"cpu_limit":   tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+	})
+
+	var diags diag.Diagnostics
+	validateContainerResources(context.Background(), config, &diags)
+	if diags.HasError() {
+		t.Errorf("validateContainerResources() = %v, want no error", diags)
+	}
+}
+
+func TestContainerResourcesToAPI(t *testing.T) {
+	model := &containerResourcesModel{
+		CPURequest:              types.StringValue("500m"),
+		CPULimit:                types.StringValue("1"),
+		MemoryRequest:           types.StringValue("512Mi"),
+		MemoryLimit:             types.StringNull(),
+		EphemeralStorageRequest: types.StringNull(),
+		EphemeralStorageLimit:   types.StringValue("10Gi"),
+		MaxDuration:             types.StringValue("2h"),
+	}
+
+	got, diags := model.toAPI()
+	if diags.HasError() {
+		t.Fatalf("toAPI() = %v", diags)
+	}
+	want := datahub.ResourcesConfig{
+		CPURequest:            "500m",
+		CPULimit:              "1",
+		MemoryRequest:         "512Mi",
+		EphemeralStorageLimit: "10Gi",
+		MaxDuration:           2 * time.Hour,
+	}
+	if *got != want {
+		t.Errorf("toAPI() = %+v, want %+v", *got, want)
+	}
+
+	// Without a block the job is reset to the engine defaults
+	var empty *containerResourcesModel
+	if got, _ := empty.toAPI(); *got != (datahub.ResourcesConfig{}) {
+		t.Errorf("toAPI() of nil = %+v, want an empty config", *got)
+	}
+}
+
+func TestContainerResourcesToAPIInvalidDuration(t *testing.T) {
+	model := &containerResourcesModel{MaxDuration: types.StringValue("soon")}
+
+	_, diags := model.toAPI()
+	if len(diags) != 1 {
+		t.Fatalf("toAPI() = %v, want one error", diags)
+	}
+	if got := diagnosticPath(diags[0]); got == nil || !got.Equal(path.Root("resources").AtName("max_duration")) {
+		t.Errorf("diagnostic path = %v, want resources.max_duration", got)
+	}
+}
+
+func TestContainerResourcesFromAPI(t *testing.T) {
+	state := &containerResourcesModel{
+		CPURequest:  types.StringValue("0.5"),
+		CPULimit:    types.StringValue("1"),
+		MaxDuration: types.StringValue("90m"),
+	}
+	config := &datahub.ResourcesConfig{
+		CPURequest:    "500m",
+		CPULimit:      "2",
+		MemoryRequest: "512Mi",
+		MaxDuration:   90 * time.Minute,
+	}
+
+	got := containerResourcesFromAPI(state, config)
+
+	// Equal quantities and durations keep the notation of the state
+	tests := []struct {
+		name string
+		got  types.String
+		want types.String
+	}{
+		{"cpu_request", got.CPURequest, types.StringValue("0.5")},
+		{"cpu_limit", got.CPULimit, types.StringValue("2")},
+		{"memory_request", got.MemoryRequest, types.StringValue("512Mi")},
+		{"memory_limit", got.MemoryLimit, types.StringNull()},
+		{"max_duration", got.MaxDuration, types.StringValue("90m")},
+	}
+	for _, tt := range tests {
+		if !tt.got.Equal(tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if got := containerResourcesFromAPI(state, &datahub.ResourcesConfig{}); got != nil {
+		t.Errorf("containerResourcesFromAPI() of an empty config = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,195 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultProfile is used when no profile is configured.
+	defaultProfile = "default"
+
+	// credentialsCommandTimeout bounds how long a credentials_command may run.
+	credentialsCommandTimeout = 2 * time.Minute
+)
+
+// credentials holds the values a credential source provides, empty strings
+// are values the source doesn't set.
+type credentials struct {
+	BaseURL            string `json:"base_url"`
+	ClientID           string `json:"client_id"`
+	ClientSecret       string `json:"client_secret"`
+	CredentialsCommand string `json:"credentials_command"`
+}
+
+// defaultCredentialsFilePath returns the credentials file from
+// DATAHUB_CREDENTIALS_FILE or ~/.datahub/credentials.
+func defaultCredentialsFilePath(getenv func(string) string) string {
+	if path := getenv("DATAHUB_CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".datahub", "credentials")
+}
+
+// loadCredentialsProfile reads the profile from the credentials file. A
+// missing file or profile is only an error when the profile was chosen
+// explicitly, otherwise no credentials are returned.
+func loadCredentialsProfile(path string, profile string, explicit bool) (*credentials, error) {
+	if path == "" {
+		if explicit {
+			return nil, fmt.Errorf("profile %q is set, but the location of the credentials file can't be determined", profile)
+		}
+		return &credentials{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return &credentials{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading credentials file: %w", err)
+	}
+
+	profiles, err := parseCredentialsFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing credentials file %s: %w", path, err)
+	}
+
+	creds, ok := profiles[profile]
+	if !ok {
+		if explicit {
+			return nil, fmt.Errorf("profile %q not found in credentials file %s", profile, path)
+		}
+		return &credentials{}, nil
+	}
+	return creds, nil
+}
+
+// parseCredentialsFile parses the profiles of a credentials file, either a
+// JSON object of profiles by name or an INI file with a section per profile:
+//
+//	[tenant-a]
+//	client_id     = ...
+//	client_secret = ...
+func parseCredentialsFile(data []byte) (map[string]*credentials, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		profiles := map[string]*credentials{}
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&profiles); err != nil {
+			return nil, err
+		}
+		return profiles, nil
+	}
+
+	profiles := map[string]*credentials{}
+	var current *credentials
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			// Accept the [profile name] form of other tools as well
+			name = strings.TrimSpace(strings.TrimPrefix(name, "profile "))
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty profile name", lineNumber)
+			}
+
+			current = &credentials{}
+			profiles[name] = current
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNumber)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: %s is not part of a profile", lineNumber, strings.TrimSpace(key))
+		}
+
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "base_url":
+			current.BaseURL = value
+		case "client_id":
+			current.ClientID = value
+		case "client_secret":
+			current.ClientSecret = value
+		case "credentials_command":
+			current.CredentialsCommand = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %s", lineNumber, strings.TrimSpace(key))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// runCredentialsCommand runs the command through the shell and reads the
+// credentials from the JSON object it writes to stdout, like
+// {"client_id": "...", "client_secret": "..."}.
+func runCredentialsCommand(ctx context.Context, command string) (*credentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, credentialsCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("running credentials command: %w: %s", err, message)
+		}
+		return nil, fmt.Errorf("running credentials command: %w", err)
+	}
+
+	var creds credentials
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return nil, fmt.Errorf("credentials command didn't write a JSON object to stdout: %w", err)
+	}
+	if creds.CredentialsCommand != "" {
+		return nil, errors.New("credentials command must not return another credentials_command")
+	}
+	return &creds, nil
+}
+
+// firstNonEmpty returns the first value that isn't empty.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
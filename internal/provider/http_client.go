@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	// defaultRequestTimeout bounds a request when request_timeout isn't configured.
+	defaultRequestTimeout = 30 * time.Second
+
+	// defaultMaxIdleConnections is used when max_idle_connections isn't configured.
+	defaultMaxIdleConnections = 100
+
+	// userAgentProduct identifies the provider in the User-Agent header.
+	userAgentProduct = "terraform-provider-datahub"
+)
+
+// httpClientConfig configures the HTTP clients of the provider.
+type httpClientConfig struct {
+	// proxy selects the proxy per request, like HTTPS_PROXY and NO_PROXY.
+	proxy *httpproxy.Config
+
+	requestTimeout     time.Duration
+	maxIdleConnections int
+	userAgent          string
+
+	// limiter bounds the requests of the client, nil means unlimited.
+	limiter *requestLimiter
+
+	// recordAPIErrors records failed responses for apiErrorDiagnostics,
+	// only set for the Datahub API.
+	recordAPIErrors bool
+
+	// trace logs every request and response, with secrets redacted.
+	trace bool
+
+	// secrets are the credentials of the provider, masked in traces
+	// wherever they appear.
+	secrets []string
+}
+
+// apiTLSConfig is the TLS configuration of every request to the Datahub API,
+// including its token endpoint. The provider has always reached the API
+// without verifying its certificate, so a TLS failure there is a failed
+// handshake, never an untrusted certificate.
+func apiTLSConfig() *tls.Config {
+	return &tls.Config{InsecureSkipVerify: true}
+}
+
+// newHTTPClient returns an HTTP client going through the configured proxy
+// and identifying itself with the provider User-Agent. A nil tlsConfig uses
+// the default TLS settings.
+func newHTTPClient(config httpClientConfig, tlsConfig *tls.Config) *http.Client {
+	proxyFunc := config.proxy.ProxyFunc()
+
+	transport := &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		},
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSClientConfig:       tlsConfig,
+		MaxIdleConns:          config.maxIdleConnections,
+		MaxIdleConnsPerHost:   config.maxIdleConnections,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	// The timeout starts once the limiter lets the request through, waiting
+	// for the limiter doesn't count towards it
+	var roundTripper http.RoundTripper = &timeoutTransport{base: transport, timeout: config.requestTimeout}
+	if config.trace {
+		roundTripper = &tracingTransport{base: roundTripper, secrets: config.secrets}
+	}
+	if config.recordAPIErrors {
+		roundTripper = &apiErrorTransport{base: roundTripper}
+	}
+	if config.limiter != nil {
+		roundTripper = &limitedTransport{base: roundTripper, limiter: config.limiter}
+	}
+
+	// The span includes the wait for the limiter, so throttling shows up
+	roundTripper = &spanTransport{base: roundTripper}
+
+	return &http.Client{
+		Transport: &userAgentTransport{base: roundTripper, userAgent: config.userAgent},
+	}
+}
+
+// userAgent returns the User-Agent of the provider, like
+// "terraform-provider-datahub/1.2.0 Terraform/1.9.0 build-agent-7".
+func userAgent(providerVersion, terraformVersion, suffix string) string {
+	parts := []string{userAgentProduct + "/" + providerVersion}
+	if terraformVersion != "" {
+		parts = append(parts, "Terraform/"+terraformVersion)
+	}
+	if suffix = strings.TrimSpace(suffix); suffix != "" {
+		parts = append(parts, suffix)
+	}
+	return strings.Join(parts, " ")
+}
+
+// userAgentTransport sets the User-Agent header on every request.
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request it is given
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// timeoutTransport bounds a request, including reading its response, to the
+// timeout.
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &onCloseBody{ReadCloser: resp.Body, onClose: cancel}
+	return resp, nil
+}
+
+// onCloseBody calls onClose once the response body is closed.
+type onCloseBody struct {
+	io.ReadCloser
+	onClose func()
+}
+
+func (b *onCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.onClose()
+	return err
+}
@@ -0,0 +1,292 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// redacted replaces sensitive values in logged requests and responses.
+	redacted = "REDACTED"
+
+	// maxTracedBodySize is the largest body that is logged, larger bodies
+	// can't be redacted completely and are left out.
+	maxTracedBodySize = 64 << 10
+)
+
+// sensitiveHeaders are headers whose values are never logged.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// sensitiveKeys are the normalized names, see normalizeKey, of JSON keys,
+// form fields and query parameters whose values are never logged.
+var sensitiveKeys = map[string]bool{
+	"clientsecret":  true,
+	"secret":        true,
+	"password":      true,
+	"token":         true,
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"idtoken":       true,
+	"subjecttoken":  true,
+	"oidctoken":     true,
+	"authorization": true,
+	"apikey":        true,
+
+	// password of datahub_registry_credential
+	"registrypassword": true,
+
+	// hmac_secret and url of datahub_notification_channel, the URL of a
+	// Slack or Teams webhook is a secret itself
+	"hmacsecret": true,
+	"url":        true,
+	"webhookurl": true,
+}
+
+// jwtRegexp matches JSON Web Tokens, like the OIDC ID tokens of CI systems,
+// wherever they appear.
+var jwtRegexp = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// secretMapKeys are the normalized names of JSON objects, like the secrets
+// of a job, of which every value is sensitive.
+var secretMapKeys = map[string]bool{
+	"secrets":   true,
+	"secretswo": true,
+}
+
+// normalizeKey makes client_secret, clientSecret and ClientSecret alike.
+func normalizeKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, key)
+}
+
+// isSensitiveKey reports whether the value of key must not be logged.
+func isSensitiveKey(key string) bool {
+	return sensitiveKeys[normalizeKey(key)]
+}
+
+// redactHeaders returns a copy of the headers with sensitive values replaced.
+func redactHeaders(header http.Header) http.Header {
+	header = header.Clone()
+	for name, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] || isSensitiveKey(name) {
+			for i := range values {
+				values[i] = redacted
+			}
+		}
+	}
+	return header
+}
+
+// redactURL returns the URL with its password and sensitive query parameters
+// replaced.
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	if query := u.Query(); len(query) > 0 {
+		redactedURL.RawQuery = redactValues(query).Encode()
+	}
+	return redactedURL.Redacted()
+}
+
+// redactValues returns a copy of form or query values with sensitive values
+// replaced.
+func redactValues(values url.Values) url.Values {
+	result := url.Values{}
+	for key, vs := range values {
+		for _, v := range vs {
+			if isSensitiveKey(key) {
+				v = redacted
+			}
+			result.Add(key, v)
+		}
+	}
+	return result
+}
+
+// isSecretPath reports whether the request addresses a secret itself, like
+// the secrets endpoint of a job, in which case every value of its body is
+// sensitive.
+func isSecretPath(u *url.URL) bool {
+	for _, segment := range strings.Split(u.Path, "/") {
+		if secretMapKeys[normalizeKey(segment)] {
+			return true
+		}
+	}
+	return false
+}
+
+// redactBody returns the body as it can be logged. JSON and form bodies are
+// logged with sensitive values replaced, other bodies only when they are
+// text. With redactAll every value of the body is replaced.
+func redactBody(contentType string, body []byte, redactAll bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if len(body) > maxTracedBodySize {
+		return fmt.Sprintf("(%d bytes, too large to log)", len(body))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || json.Valid(body):
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Sprintf("(%d bytes of invalid JSON)", len(body))
+		}
+		redactedBody, err := json.Marshal(redactJSON(value, redactAll))
+		if err != nil {
+			return fmt.Sprintf("(%d bytes of JSON)", len(body))
+		}
+		return string(redactedBody)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("(%d bytes of invalid form data)", len(body))
+		}
+		if redactAll {
+			for key := range values {
+				values[key] = []string{redacted}
+			}
+			return values.Encode()
+		}
+		return redactValues(values).Encode()
+	case strings.HasPrefix(mediaType, "text/") && !redactAll:
+		return string(body)
+	default:
+		return fmt.Sprintf("(%d bytes of %s)", len(body), firstNonEmpty(mediaType, "unknown content"))
+	}
+}
+
+// redactJSON replaces the values of sensitive keys and of secret maps in a
+// decoded JSON value, or all values with redactAll.
+func redactJSON(value any, redactAll bool) any {
+	switch value := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(value))
+		for key, element := range value {
+			// The keys are kept, so the names of secrets still show up
+			sensitive := redactAll || isSensitiveKey(key) || secretMapKeys[normalizeKey(key)]
+			result[key] = redactJSON(element, sensitive)
+		}
+		return result
+	case []any:
+		result := make([]any, len(value))
+		for i, element := range value {
+			result[i] = redactJSON(element, redactAll)
+		}
+		return result
+	case nil:
+		return nil
+	default:
+		if redactAll {
+			return redacted
+		}
+		return value
+	}
+}
+
+// maskSecrets replaces every occurrence of the secrets and of JWTs in s, as
+// a last resort for secrets in places the other rules don't know about.
+func maskSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return jwtRegexp.ReplaceAllString(s, redacted)
+}
+
+// tracingTransport logs every request and response through tflog, with
+// sensitive values redacted.
+type tracingTransport struct {
+	base http.RoundTripper
+
+	// secrets are the credentials of the provider, masked wherever they
+	// appear.
+	secrets []string
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	secretPath := isSecretPath(req.URL)
+
+	fields := map[string]any{
+		"method":          req.Method,
+		"url":             maskSecrets(redactURL(req.URL), t.secrets),
+		"request_headers": t.headers(req.Header),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(ctx)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		fields["request_body"] = maskSecrets(redactBody(req.Header.Get("Content-Type"), body, secretPath), t.secrets)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	fields["duration"] = time.Since(start).String()
+	if err != nil {
+		fields["error"] = maskSecrets(err.Error(), t.secrets)
+		tflog.Debug(ctx, "Datahub HTTP request failed", fields)
+		return nil, err
+	}
+
+	fields["status"] = resp.StatusCode
+	fields["response_headers"] = t.headers(resp.Header)
+
+	// Only read as much of the response as is logged, the caller reads the
+	// rest from the original body
+	head, err := io.ReadAll(io.LimitReader(resp.Body, maxTracedBodySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	if len(head) > maxTracedBodySize {
+		fields["response_body"] = fmt.Sprintf("(more than %d bytes, too large to log)", maxTracedBodySize)
+	} else {
+		fields["response_body"] = maskSecrets(redactBody(resp.Header.Get("Content-Type"), head, secretPath), t.secrets)
+	}
+
+	tflog.Debug(ctx, "Datahub HTTP request", fields)
+	return resp, nil
+}
+
+// headers formats the redacted headers for the log.
+func (t *tracingTransport) headers(header http.Header) map[string]string {
+	result := map[string]string{}
+	for name, values := range redactHeaders(header) {
+		result[name] = maskSecrets(strings.Join(values, ", "), t.secrets)
+	}
+	return result
+}
@@ -0,0 +1,315 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// dockerHubRegistry is the registry host images without a registry resolve to.
+	dockerHubRegistry = "registry-1.docker.io"
+
+	// dockerHubConfigKey is the key Docker uses for Docker Hub in config.json.
+	dockerHubConfigKey = "https://index.docker.io/v1/"
+)
+
+// manifestMediaTypes are the manifest types accepted when resolving a digest,
+// indexes first so multi-platform images resolve to their index digest.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// authParamRegexp matches the key="value" parameters of a WWW-Authenticate header.
+var authParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// imageDigestResolver resolves image tags to manifest digests through the OCI
+// distribution API. Registries on localhost are contacted over plain http, so
+// a local registry can stand in for the real one.
+type imageDigestResolver struct {
+	httpClient *http.Client
+
+	// dockerConfigPath is the Docker config.json credentials are read from,
+	// registries without credentials in it are accessed anonymously.
+	dockerConfigPath string
+}
+
+// newImageDigestResolver returns a resolver reading credentials from the
+// Docker config in DOCKER_CONFIG or ~/.docker.
+func newImageDigestResolver(httpClient *http.Client) *imageDigestResolver {
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configDir = filepath.Join(home, ".docker")
+		}
+	}
+
+	return &imageDigestResolver{
+		httpClient:       httpClient,
+		dockerConfigPath: filepath.Join(configDir, "config.json"),
+	}
+}
+
+// Resolve returns the digest, like sha256:..., the image currently points to.
+func (r *imageDigestResolver) Resolve(ctx context.Context, image string) (string, error) {
+	registry, repository, reference := parseImageReference(image)
+	if strings.Contains(reference, ":") {
+		// Already pinned to a digest
+		return reference, nil
+	}
+
+	scheme := "https"
+	if isLocalRegistry(registry) {
+		scheme = "http"
+	}
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, registry, repository, reference)
+
+	resp, err := r.fetchManifest(ctx, http.MethodHead, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err := r.authorize(ctx, resp.Header.Get("WWW-Authenticate"), registry, repository)
+		if err != nil {
+			return "", fmt.Errorf("authenticating to %s: %w", registry, err)
+		}
+
+		resp, err = r.fetchManifest(ctx, http.MethodHead, manifestURL, authorization)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+
+		if digest := resp.Header.Get("Docker-Content-Digest"); resp.StatusCode == http.StatusOK && digest != "" {
+			return digest, nil
+		}
+		return r.digestFromBody(ctx, manifestURL, authorization)
+	}
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); resp.StatusCode == http.StatusOK && digest != "" {
+		return digest, nil
+	}
+	return r.digestFromBody(ctx, manifestURL, "")
+}
+
+// digestFromBody fetches the manifest and computes its digest, for
+// registries that don't return a Docker-Content-Digest header on HEAD.
+func (r *imageDigestResolver) digestFromBody(ctx context.Context, manifestURL string, authorization string) (string, error) {
+	resp, err := r.fetchManifest(ctx, http.MethodGet, manifestURL, authorization)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching manifest %s: unexpected status %s", manifestURL, resp.Status)
+	}
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return "", fmt.Errorf("reading manifest %s: %w", manifestURL, err)
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (r *imageDigestResolver) fetchManifest(ctx context.Context, method string, manifestURL string, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching manifest %s: %w", manifestURL, err)
+	}
+	return resp, nil
+}
+
+// authorize answers the registry's WWW-Authenticate challenge and returns the
+// Authorization header to retry the request with.
+func (r *imageDigestResolver) authorize(ctx context.Context, challenge string, registry string, repository string) (string, error) {
+	username, password := r.credentials(registry)
+
+	scheme, _, _ := strings.Cut(challenge, " ")
+	if strings.EqualFold(scheme, "Basic") {
+		if username == "" {
+			return "", fmt.Errorf("registry requires credentials, none found in %s", r.dockerConfigPath)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+	}
+
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	params := map[string]string{}
+	for _, match := range authParamRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("authentication challenge %q has no realm", challenge)
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", fmt.Errorf("invalid token realm %q: %w", params["realm"], err)
+	}
+	query := tokenURL.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	query.Set("scope", "repository:"+repository+":pull")
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetching registry token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching registry token: unexpected status %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding registry token: %w", err)
+	}
+
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// credentials returns the username and password for the registry from the
+// Docker config, or empty strings when there are none.
+func (r *imageDigestResolver) credentials(registry string) (string, string) {
+	data, err := os.ReadFile(r.dockerConfigPath)
+	if err != nil {
+		return "", ""
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", ""
+	}
+
+	key := registry
+	if registry == dockerHubRegistry {
+		key = dockerHubConfigKey
+	}
+
+	for server, auth := range config.Auths {
+		if server != key && strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://") != key {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return "", ""
+		}
+		username, password, _ := strings.Cut(string(decoded), ":")
+		return username, password
+	}
+
+	return "", ""
+}
+
+// parseImageReference splits an image reference into the registry host, the
+// repository and the tag or digest, applying the Docker Hub defaults.
+func parseImageReference(image string) (registry string, repository string, reference string) {
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		reference = name[i+1:]
+		name = name[:i]
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		if reference == "" {
+			reference = name[i+1:]
+		}
+		name = name[:i]
+	}
+
+	if reference == "" {
+		reference = "latest"
+	}
+
+	registry = dockerHubRegistry
+	if i := strings.Index(name, "/"); i >= 0 && (strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		registry = name[:i]
+		name = name[i+1:]
+	}
+
+	if registry == "docker.io" || registry == "index.docker.io" {
+		registry = dockerHubRegistry
+	}
+
+	if registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+
+	return registry, name, reference
+}
+
+// splitImageDigest splits an image reference into the image without digest
+// and the digest, which is empty when the reference isn't pinned.
+func splitImageDigest(image string) (string, string) {
+	name, digest, _ := strings.Cut(image, "@")
+	return name, digest
+}
+
+// isLocalRegistry reports whether the registry host runs on this machine.
+func isLocalRegistry(registry string) bool {
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,335 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testManifestDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	testIndexDigest    = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	testRegistryToken  = "registry-token"
+)
+
+// testRegistry is a stand-in for an OCI registry serving one repository.
+type testRegistry struct {
+	// manifests are the manifests by tag, with the media type they are
+	// served as.
+	manifests map[string]testManifest
+
+	// bearer requires a token from the token endpoint, basic requires the
+	// credentials of basicAuth.
+	bearer    bool
+	basic     bool
+	basicAuth string
+
+	// headDigest sets Docker-Content-Digest on HEAD responses.
+	headDigest bool
+
+	// tokenStatus is the status of the token endpoint, 200 when 0.
+	tokenStatus int
+
+	// tokenScopes are the scopes the token endpoint was asked for.
+	tokenScopes []string
+}
+
+type testManifest struct {
+	mediaType string
+	digest    string
+	body      string
+}
+
+func (tr *testRegistry) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tr.tokenScopes = append(tr.tokenScopes, r.URL.Query().Get("scope"))
+		if tr.tokenStatus != 0 {
+			w.WriteHeader(tr.tokenStatus)
+			return
+		}
+		fmt.Fprintf(w, `{"token": %q}`, testRegistryToken)
+	})
+	mux.HandleFunc("/v2/team/app/manifests/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case tr.bearer && r.Header.Get("Authorization") != "Bearer "+testRegistryToken:
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		case tr.basic && r.Header.Get("Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(tr.basicAuth)):
+			w.Header().Set("WWW-Authenticate", `Basic realm="test-registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		tag := strings.TrimPrefix(r.URL.Path, "/v2/team/app/manifests/")
+		manifest, ok := tr.manifests[tag]
+		if !ok || !strings.Contains(r.Header.Get("Accept"), manifest.mediaType) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", manifest.mediaType)
+		if r.Method == http.MethodGet || tr.headDigest {
+			w.Header().Set("Docker-Content-Digest", manifest.digest)
+		}
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, manifest.body)
+		}
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testImage returns the image reference of the repository of the registry.
+func testImage(server *httptest.Server, reference string) string {
+	return strings.TrimPrefix(server.URL, "http://") + "/team/app" + reference
+}
+
+func newTestImageDigestResolver(t *testing.T) *imageDigestResolver {
+	t.Helper()
+	return &imageDigestResolver{
+		httpClient:       http.DefaultClient,
+		dockerConfigPath: filepath.Join(t.TempDir(), "config.json"),
+	}
+}
+
+func TestImageDigestResolverResolve(t *testing.T) {
+	manifests := map[string]testManifest{
+		"1.0": {
+			mediaType: "application/vnd.oci.image.manifest.v1+json",
+			digest:    testManifestDigest,
+			body:      `{"schemaVersion": 2}`,
+		},
+		"multi": {
+			mediaType: "application/vnd.oci.image.index.v1+json",
+			digest:    testIndexDigest,
+			body:      `{"schemaVersion": 2, "manifests": []}`,
+		},
+		"list": {
+			mediaType: "application/vnd.docker.distribution.manifest.list.v2+json",
+			digest:    testIndexDigest,
+			body:      `{"schemaVersion": 2, "manifests": []}`,
+		},
+	}
+
+	tests := []struct {
+		name      string
+		registry  testRegistry
+		reference string
+		want      string
+	}{
+		{
+			name:      "tag with digest header on HEAD",
+			registry:  testRegistry{manifests: manifests, headDigest: true},
+			reference: ":1.0",
+			want:      testManifestDigest,
+		},
+		{
+			name:      "tag with digest only on GET",
+			registry:  testRegistry{manifests: manifests},
+			reference: ":1.0",
+			want:      testManifestDigest,
+		},
+		{
+			name:      "OCI image index",
+			registry:  testRegistry{manifests: manifests, headDigest: true},
+			reference: ":multi",
+			want:      testIndexDigest,
+		},
+		{
+			name:      "Docker manifest list",
+			registry:  testRegistry{manifests: manifests},
+			reference: ":list",
+			want:      testIndexDigest,
+		},
+		{
+			name:      "bearer token challenge",
+			registry:  testRegistry{manifests: manifests, bearer: true, headDigest: true},
+			reference: ":1.0",
+			want:      testManifestDigest,
+		},
+		{
+			name:      "bearer token challenge without digest header",
+			registry:  testRegistry{manifests: manifests, bearer: true},
+			reference: ":multi",
+			want:      testIndexDigest,
+		},
+		{
+			name:      "existing digest",
+			registry:  testRegistry{},
+			reference: "@" + testManifestDigest,
+			want:      testManifestDigest,
+		},
+		{
+			name:      "tag and digest",
+			registry:  testRegistry{},
+			reference: ":1.0@" + testIndexDigest,
+			want:      testIndexDigest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.registry.serve(t)
+
+			got, err := newTestImageDigestResolver(t).Resolve(context.Background(), testImage(server, tt.reference))
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageDigestResolverTokenScope(t *testing.T) {
+	registry := testRegistry{
+		manifests: map[string]testManifest{
+			"1.0": {mediaType: "application/vnd.oci.image.manifest.v1+json", digest: testManifestDigest},
+		},
+		bearer:     true,
+		headDigest: true,
+	}
+	server := registry.serve(t)
+
+	if _, err := newTestImageDigestResolver(t).Resolve(context.Background(), testImage(server, ":1.0")); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := []string{"repository:team/app:pull"}; strings.Join(registry.tokenScopes, ",") != strings.Join(want, ",") {
+		t.Errorf("token scopes = %v, want %v", registry.tokenScopes, want)
+	}
+}
+
+func TestImageDigestResolverComputesDigestOfBody(t *testing.T) {
+	body := `{"schemaVersion": 2}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	got, err := newTestImageDigestResolver(t).Resolve(context.Background(), testImage(server, ":1.0"))
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	sum := sha256.Sum256([]byte(body))
+	if want := "sha256:" + hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("Resolve() = %q, want %q", got, want)
+	}
+}
+
+func TestImageDigestResolverBasicAuth(t *testing.T) {
+	registry := testRegistry{
+		manifests: map[string]testManifest{
+			"1.0": {mediaType: "application/vnd.oci.image.manifest.v1+json", digest: testManifestDigest},
+		},
+		basic:      true,
+		basicAuth:  "robot:secret",
+		headDigest: true,
+	}
+	server := registry.serve(t)
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	resolver := newTestImageDigestResolver(t)
+	config := fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, host, base64.StdEncoding.EncodeToString([]byte("robot:secret")))
+	if err := os.WriteFile(resolver.dockerConfigPath, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := resolver.Resolve(context.Background(), testImage(server, ":1.0"))
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got != testManifestDigest {
+		t.Errorf("Resolve() = %q, want %q", got, testManifestDigest)
+	}
+}
+
+func TestImageDigestResolverErrors(t *testing.T) {
+	manifests := map[string]testManifest{
+		"1.0": {mediaType: "application/vnd.oci.image.manifest.v1+json", digest: testManifestDigest},
+	}
+
+	tests := []struct {
+		name      string
+		registry  testRegistry
+		reference string
+		wantErr   string
+	}{
+		{
+			name:      "unknown tag",
+			registry:  testRegistry{manifests: manifests},
+			reference: ":2.0",
+			wantErr:   "404 Not Found",
+		},
+		{
+			name:      "unknown tag after token challenge",
+			registry:  testRegistry{manifests: manifests, bearer: true},
+			reference: ":2.0",
+			wantErr:   "404 Not Found",
+		},
+		{
+			name:      "token endpoint rejects the request",
+			registry:  testRegistry{manifests: manifests, bearer: true, tokenStatus: http.StatusUnauthorized},
+			reference: ":1.0",
+			wantErr:   "fetching registry token: unexpected status 401 Unauthorized",
+		},
+		{
+			name:      "basic challenge without credentials",
+			registry:  testRegistry{manifests: manifests, basic: true, basicAuth: "robot:secret"},
+			reference: ":1.0",
+			wantErr:   "registry requires credentials",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.registry.serve(t)
+
+			_, err := newTestImageDigestResolver(t).Resolve(context.Background(), testImage(server, tt.reference))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Resolve() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		image                           string
+		registry, repository, reference string
+	}{
+		{"alpine", dockerHubRegistry, "library/alpine", "latest"},
+		{"alpine:3.20", dockerHubRegistry, "library/alpine", "3.20"},
+		{"docker.io/team/app:1.0", dockerHubRegistry, "team/app", "1.0"},
+		{"ghcr.io/team/app", "ghcr.io", "team/app", "latest"},
+		{"localhost:5000/app:dev", "localhost:5000", "app", "dev"},
+		{"ghcr.io/team/app:1.0@" + testManifestDigest, "ghcr.io", "team/app", testManifestDigest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			registry, repository, reference := parseImageReference(tt.image)
+			if registry != tt.registry || repository != tt.repository || reference != tt.reference {
+				t.Errorf("parseImageReference(%q) = %q, %q, %q, want %q, %q, %q",
+					tt.image, registry, repository, reference, tt.registry, tt.repository, tt.reference)
+			}
+		})
+	}
+}
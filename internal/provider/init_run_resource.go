@@ -3,12 +3,15 @@ package provider
 import (
 	"context"
 	// "encoding/json"
-	"fmt"
 
 	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
 
-	// "github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
@@ -16,16 +19,19 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	// "github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource              = &initRunResource{}
-	_ resource.ResourceWithConfigure = &initRunResource{}
+	_ resource.Resource                   = &initRunResource{}
+	_ resource.ResourceWithConfigure      = &initRunResource{}
+	_ resource.ResourceWithValidateConfig = &initRunResource{}
 	// _ resource.ResourceWithImportState = &initRunResource{}
 )
 
@@ -37,7 +43,7 @@ func NewInitRunResource() resource.Resource {
 // initRunResource is the resource implementation.
 // initRunResource is the resource implementation.
 type initRunResource struct {
-	client *datahub.DatahubClient
+	tenants *tenantClients
 }
 
 // Metadata returns the resource type name.
@@ -65,6 +71,7 @@ func (r *initRunResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"act_as_client_id": actAsClientIDAttribute("job of the run"),
 			"name": schema.StringAttribute{
 				Description: "Name of the InitRun, needs to be unique for the current client",
 				Required:    true,
@@ -82,6 +89,19 @@ func (r *initRunResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 				},
+				Validators: []validator.String{
+					imageReferenceValidator{},
+				},
+			},
+			"image_pull_credential_id": schema.StringAttribute{
+				Description: "credential_id of the datahub_registry_credential used to pull the image from a private registry",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					uuidValidator(),
+				},
 			},
 			"environment": schema.MapAttribute{
 				ElementType: types.StringType,
@@ -89,6 +109,9 @@ func (r *initRunResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 				PlanModifiers: []planmodifier.Map{
 					mapplanmodifier.RequiresReplace(),
 				},
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(envVarNameValidator{}),
+				},
 			},
 			"secrets": schema.MapAttribute{
 				ElementType: types.StringType,
@@ -97,6 +120,9 @@ func (r *initRunResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 				PlanModifiers: []planmodifier.Map{
 					mapplanmodifier.RequiresReplace(),
 				},
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(envVarNameValidator{}),
+				},
 			},
 			"command": schema.ListAttribute{
 				ElementType: types.StringType,
@@ -105,6 +131,17 @@ func (r *initRunResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 				PlanModifiers: []planmodifier.List{
 					listplanmodifier.RequiresReplace(),
 				},
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
+			"resources": schema.SingleNestedAttribute{
+				Description: "CPU, memory and ephemeral storage requests and limits, and the maximum runtime of the init run's container. Quantities use the Kubernetes notation.",
+				Optional:    true,
+				Attributes:  containerResourcesAttributes(),
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.RequiresReplace(),
+				},
 			},
 			// "oauth": schema.SingleNestedAttribute{
 			// 	Description: "The OAuth config for logging into external service",
@@ -142,11 +179,18 @@ func (r *initRunResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 			},
 		},
 	}
+
+	for name, attribute := range writeOnlySecretsAttributes(true) {
+		resp.Schema.Attributes[name] = attribute
+	}
 }
 
 // Create creates the resource and sets the initial Terraform state.
 // Create a new resource
 func (r *initRunResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_init_run", "Create")
+	defer endSpan(span, &resp.Diagnostics)
 
 	// Retrieve values from plan
 	var runModel runResourceModel
@@ -170,6 +214,15 @@ func (r *initRunResource) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
+	secretsWO, diags := writeOnlySecrets(ctx, req.Config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if len(secretsWO) > 0 {
+		secrets = mergeSecrets(secrets, secretsWO)
+	}
+
 	var command []string
 	diags = runModel.Command.ElementsAs(ctx, &command, false)
 	resp.Diagnostics.Append(diags...)
@@ -177,68 +230,96 @@ func (r *initRunResource) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
+	var resources *datahub.ResourcesConfig
+	if runModel.Resources != nil {
+		var resourcesDiags diag.Diagnostics
+		resources, resourcesDiags = runModel.Resources.toAPI()
+		resp.Diagnostics.Append(resourcesDiags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	jobRequest := datahub.CreateJobRequest{
 		Name:        runModel.Name.ValueString(),
-		JobType:        "full",
+		JobType:     "full",
 		Image:       runModel.Image.ValueString(),
 		Environment: &environment,
 		Secrets:     &secrets,
 		Command:     &command,
+		Resources:   resources,
+	}
+
+	if !runModel.ImagePullCredentialID.IsNull() {
+		credentialID, err := uuid.Parse(runModel.ImagePullCredentialID.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error creating job",
+				"Could not parse image_pull_credential_id "+runModel.ImagePullCredentialID.ValueString()+": "+err.Error(),
+			)
+			return
+		}
+		jobRequest.ImagePullCredentialID = &credentialID
+	}
+
+	client, diags := r.tenants.get(runModel.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	job, err := r.client.Job.Create(ctx, jobRequest)
+	job, err := client.Job.Create(ctx, jobRequest)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error creating job",
-			"Could not create job, unexpected error: "+err.Error(),
-		)
+			"Could not create job",
+			err, path.Root("name"), req.Plan.Schema,
+		)...)
 		return
 	}
 
-	// tflog.Debug(ctx, fmt.Sprintf("Job config: %v", job))
-	tflog.Debug(ctx, fmt.Sprintf("Create Job object: %v", jobRequest))
-
 	runModel.JobID = types.StringValue(job.ID.String())
+	tflog.Debug(ctx, "Created job of init run", map[string]any{"job_id": runModel.JobID.ValueString()})
+	setSpanAttributes(ctx, jobIDKey.String(runModel.JobID.ValueString()))
 
-	runRequest := datahub.RunRequestOptions{
-		// JobID: jobResponse.JobID,
-	}
-
-
-	runResponse, err := r.client.Run.Create(ctx, job.ID, nil, nil, nil)
+	runResponse, err := client.Run.Create(ctx, job.ID, nil, nil, nil)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error creating run",
-			"Could not create run, unexpected error: "+err.Error(),
-		)
+			"Could not create run",
+			err, path.Empty(), req.Plan.Schema,
+		)...)
 		return
 	}
 
 	run, err := runResponse.Run(ctx)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error creating run",
-			"Could not create run, unexpected error: "+err.Error(),
-		)
+			"Could not read the created run",
+			err, path.Empty(), req.Plan.Schema,
+		)...)
 		return
 	}
 
 	runStatus, err := run.WaitForCompletion(ctx)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error creating run",
-			"Could not create run, unexpected error: "+err.Error(),
-		)
+			"Could not wait for run "+run.ID.String()+" to complete",
+			err, path.Empty(), req.Plan.Schema,
+		)...)
 		return
 	}
 
-	tflog.Debug(ctx, fmt.Sprintf("InitRun config: %v", runModel))
-	tflog.Debug(ctx, fmt.Sprintf("Create InitRun object: %v", runRequest))
-
 	runModel.RunID = types.StringValue(run.ID.String())
+	tflog.Debug(ctx, "Init run completed", map[string]any{
+		"job_id": runModel.JobID.ValueString(),
+		"run_id": runModel.RunID.ValueString(),
+		"status": runStatus.Status.Status,
+	})
 
-
-	if runStatus.Status.Status == "failed" || runStatus.Status.Status == "cancelled" || runStatus.Status.Status == "rejected"{
+	if runStatus.Status.Status == "failed" || runStatus.Status.Status == "cancelled" || runStatus.Status.Status == "rejected" {
 		runModel.Status = types.StringValue(InitRunStatusFailed)
 	} else {
 		runModel.Status = types.StringValue(InitRunStatusOK)
@@ -254,6 +335,10 @@ func (r *initRunResource) Create(ctx context.Context, req resource.CreateRequest
 
 // Read refreshes the Terraform state with the latest data.
 func (r *initRunResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_init_run", "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
 	// Get current state
 	var state runResourceModel
 	diags := req.State.Get(ctx, &state)
@@ -271,15 +356,23 @@ func (r *initRunResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
-	runStatus, err := r.client.Run.Status(ctx, runID)
+	client, diags := r.tenants.get(state.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	runStatus, err := client.Run.Status(ctx, runID)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error Reading Datahub InitRun Status",
-			"Could not read Datahub job ID "+state.RunID.ValueString()+": "+err.Error(),
-		)
+			"Could not read Datahub job ID "+state.RunID.ValueString(),
+			err, path.Empty(), req.State.Schema,
+		)...)
 		return
 	}
 
+	setSpanAttributes(ctx, jobIDKey.String(state.JobID.ValueString()))
 	jobID, err := uuid.Parse(state.JobID.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -289,15 +382,15 @@ func (r *initRunResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
-
 	if slices.Contains([]string{"failed", "cancelled", "rejected"}, runStatus.Status) {
 		// state.Status = types.StringValue(InitRunStatusFailed)
-		err = r.client.Job.Delete(ctx, jobID)
+		err = client.Job.Delete(ctx, jobID)
 		if err != nil {
-			resp.Diagnostics.AddError(
+			resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 				"Error deleting job",
-				"Could not delete job, unexpected error: "+err.Error(),
-			)
+				"Could not delete job",
+				err, path.Empty(), req.State.Schema,
+			)...)
 			return
 		}
 		resp.State.RemoveResource(ctx)
@@ -314,15 +407,23 @@ func (r *initRunResource) Read(ctx context.Context, req resource.ReadRequest, re
 
 // Update updates the resource and sets the updated Terraform state on success.
 func (r *initRunResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_init_run", "Update")
+	defer endSpan(span, &resp.Diagnostics)
+
 	resp.Diagnostics.AddError(
 		"Error updating initialise run",
 		"An initialise run should be immutable, so it should be recreated and not updated. This is an error in the plugin",
 	)
-	
+
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
 func (r *initRunResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_init_run", "Delete")
+	defer endSpan(span, &resp.Diagnostics)
+
 	var state runResourceModel
 	diags := req.State.Get(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -330,6 +431,7 @@ func (r *initRunResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
+	setSpanAttributes(ctx, jobIDKey.String(state.JobID.ValueString()))
 	jobID, err := uuid.Parse(state.JobID.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -339,24 +441,38 @@ func (r *initRunResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
-	err = r.client.Job.Delete(ctx, jobID)
+	client, diags := r.tenants.get(state.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err = client.Job.Delete(ctx, jobID)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error deleting job",
-			"Could not delete job, unexpected error: "+err.Error(),
-		)
+			"Could not delete job",
+			err, path.Empty(), req.State.Schema,
+		)...)
 		return
 	}
 
 	resp.State.RemoveResource(ctx)
 }
 
+// ValidateConfig checks the requests against the limits in the resources
+// block and the keys of secrets_wo against the environment and secrets.
+func (r *initRunResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	validateContainerResources(ctx, req.Config, &resp.Diagnostics)
+	validateWriteOnlySecrets(ctx, req.Config, &resp.Diagnostics)
+}
+
 func (r *initRunResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
 
-	r.client = req.ProviderData.(*datahub.DatahubClient)
+	r.tenants = req.ProviderData.(*datahubProviderData).tenants
 }
 
 // func (r *initRunResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
@@ -365,15 +481,20 @@ func (r *initRunResource) Configure(_ context.Context, req resource.ConfigureReq
 // }
 
 type runResourceModel struct {
-	JobID types.String `tfsdk:"job_id"`
-	RunID types.String `tfsdk:"run_id"`
-	Name  types.String `tfsdk:"name"`
+	JobID         types.String `tfsdk:"job_id"`
+	RunID         types.String `tfsdk:"run_id"`
+	ActAsClientID types.String `tfsdk:"act_as_client_id"`
+	Name          types.String `tfsdk:"name"`
 	// Type        types.String           `tfsdk:"type"`
-	Image       types.String `tfsdk:"image"`
-	Environment types.Map    `tfsdk:"environment"`
-	Secrets     types.Map    `tfsdk:"secrets"`
-	Command     types.List   `tfsdk:"command"`
-	Status      types.String `tfsdk:"status"`
+	Image                 types.String             `tfsdk:"image"`
+	ImagePullCredentialID types.String             `tfsdk:"image_pull_credential_id"`
+	Environment           types.Map                `tfsdk:"environment"`
+	Secrets               types.Map                `tfsdk:"secrets"`
+	SecretsWO             types.Map                `tfsdk:"secrets_wo"`
+	SecretsWOVersion      types.Int64              `tfsdk:"secrets_wo_version"`
+	Command               types.List               `tfsdk:"command"`
+	Resources             *containerResourcesModel `tfsdk:"resources"`
+	Status                types.String             `tfsdk:"status"`
 	// OAuth       *runResourceOauthModel `tfsdk:"oauth"`
 }
 
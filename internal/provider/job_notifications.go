@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"context"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	JobEventRunFailed           = "run_failed"
+	JobEventRunSucceeded        = "run_succeeded"
+	JobEventOAuthTokenExpired   = "oauth_token_expired"
+	JobEventRunExceededDuration = "run_exceeded_duration"
+)
+
+// jobNotificationsAttribute returns the notifications attribute of datahub_job.
+func jobNotificationsAttribute() schema.SetNestedAttribute {
+	return schema.SetNestedAttribute{
+		Description: "Notification channels to notify about events of the job",
+		Optional:    true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"channel_id": schema.StringAttribute{
+					Description: "channel_id of the datahub_notification_channel to notify",
+					Required:    true,
+					Validators: []validator.String{
+						uuidValidator(),
+					},
+				},
+				"events": schema.SetAttribute{
+					Description: "events to notify about: run_failed, run_succeeded, oauth_token_expired or run_exceeded_duration",
+					ElementType: types.StringType,
+					Required:    true,
+					Validators: []validator.Set{
+						setvalidator.ValueStringsAre(stringvalidator.OneOf(
+							JobEventRunFailed,
+							JobEventRunSucceeded,
+							JobEventOAuthTokenExpired,
+							JobEventRunExceededDuration,
+						)),
+					},
+				},
+			},
+		},
+	}
+}
+
+type jobNotificationModel struct {
+	ChannelID types.String `tfsdk:"channel_id"`
+	Events    types.Set    `tfsdk:"events"`
+}
+
+// jobNotificationsEqual reports whether both sets of notifications hold the same values.
+func jobNotificationsEqual(a, b []jobNotificationModel) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for _, notificationA := range a {
+		found := false
+		for _, notificationB := range b {
+			if notificationA.ChannelID.Equal(notificationB.ChannelID) && notificationA.Events.Equal(notificationB.Events) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// jobNotificationsToAPI converts the notifications attribute to its API representation.
+func jobNotificationsToAPI(ctx context.Context, notifications []jobNotificationModel) ([]datahub.NotificationSubscription, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	subscriptions := []datahub.NotificationSubscription{}
+
+	for _, notification := range notifications {
+		channelID, err := uuid.Parse(notification.ChannelID.ValueString())
+		if err != nil {
+			diags.AddError(
+				"Invalid Notification Channel",
+				"Could not parse channel_id "+notification.ChannelID.ValueString()+": "+err.Error(),
+			)
+			return nil, diags
+		}
+
+		subscription := datahub.NotificationSubscription{ChannelID: channelID}
+		diags.Append(notification.Events.ElementsAs(ctx, &subscription.Events, false)...)
+		if diags.HasError() {
+			return nil, diags
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, diags
+}
+
+// jobNotificationsFromAPI converts the notifications returned by the API to
+// the notifications attribute.
+func jobNotificationsFromAPI(ctx context.Context, subscriptions []datahub.NotificationSubscription) ([]jobNotificationModel, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if len(subscriptions) == 0 {
+		return nil, diags
+	}
+
+	notifications := []jobNotificationModel{}
+	for _, subscription := range subscriptions {
+		events, eventDiags := types.SetValueFrom(ctx, types.StringType, subscription.Events)
+		diags.Append(eventDiags...)
+		if diags.HasError() {
+			return nil, diags
+		}
+
+		notifications = append(notifications, jobNotificationModel{
+			ChannelID: types.StringValue(subscription.ChannelID.String()),
+			Events:    events,
+		})
+	}
+
+	return notifications, diags
+}
@@ -2,11 +2,15 @@ package provider
 
 import (
 	"context"
-	"fmt"
+	"strings"
 
 	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -14,15 +18,19 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/exp/maps"
 	// "github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &jobResource{}
-	_ resource.ResourceWithConfigure   = &jobResource{}
-	_ resource.ResourceWithImportState = &jobResource{}
+	_ resource.Resource                   = &jobResource{}
+	_ resource.ResourceWithConfigure      = &jobResource{}
+	_ resource.ResourceWithImportState    = &jobResource{}
+	_ resource.ResourceWithValidateConfig = &jobResource{}
+	_ resource.ResourceWithModifyPlan     = &jobResource{}
 )
 
 // NewJobResource is a helper function to simplify the provider implementation.
@@ -33,7 +41,8 @@ func NewJobResource() resource.Resource {
 // jobResource is the resource implementation.
 // jobResource is the resource implementation.
 type jobResource struct {
-	client *datahub.DatahubClient
+	tenants             *tenantClients
+	imageDigestResolver *imageDigestResolver
 }
 
 // Metadata returns the resource type name.
@@ -54,6 +63,7 @@ func (r *jobResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"act_as_client_id": actAsClientIDAttribute("job"),
 			"name": schema.StringAttribute{
 				Description: "Name of the Job, needs to be unique for the current client",
 				Required:    true,
@@ -65,21 +75,76 @@ func (r *jobResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *
 			"image": schema.StringAttribute{
 				Description: "Docker image for the job",
 				Required:    true,
+				Validators: []validator.String{
+					imageReferenceValidator{},
+				},
+			},
+			"pin_digest": schema.BoolAttribute{
+				Description: "Resolve the image tag to its digest during plan and run exactly that digest. A tag that is pushed again shows up as a change to image_digest.",
+				Optional:    true,
+			},
+			"image_digest": schema.StringAttribute{
+				Description: "Digest the image was pinned to when pin_digest is true.",
+				Computed:    true,
+			},
+			"image_pull_credential_id": schema.StringAttribute{
+				Description: "credential_id of the datahub_registry_credential used to pull the image from a private registry",
+				Optional:    true,
+				Validators: []validator.String{
+					uuidValidator(),
+				},
 			},
 			"environment": schema.MapAttribute{
 				ElementType: types.StringType,
 				Optional:    true,
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(envVarNameValidator{}),
+				},
 			},
 			"secrets": schema.MapAttribute{
 				ElementType: types.StringType,
 				Optional:    true,
 				Sensitive:   true,
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(envVarNameValidator{}),
+				},
+			},
+			"secrets_wo_keys": schema.SetAttribute{
+				Description: "Keys of secrets_wo, used to remove secrets that are no longer configured.",
+				ElementType: types.StringType,
+				Computed:    true,
 			},
+			"secret_refs": secretRefsAttribute(),
 			"command": schema.ListAttribute{
 				ElementType: types.StringType,
 				Optional:    true,
 				Description: "Command to be executed in the container as a list of arguments",
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
+			"deletion_protection": schema.BoolAttribute{
+				Description: "Prevents the job from being deleted or replaced while true. Must be set to false in a separate apply before the job can be destroyed.",
+				Optional:    true,
 			},
+			"resources": schema.SingleNestedAttribute{
+				Description: "CPU, memory and ephemeral storage requests and limits, and the maximum runtime of the job's container. Quantities use the Kubernetes notation.",
+				Optional:    true,
+				Attributes:  containerResourcesAttributes(),
+			},
+			"retry_policy": schema.SingleNestedAttribute{
+				Description: "How the engine retries failed runs of the job. Without it a failed run is not retried.",
+				Optional:    true,
+				Attributes:  retryPolicyAttributes(),
+			},
+			"failure_policy": schema.StringAttribute{
+				Description: "What happens when a run failed after all attempts: fail (default) marks the run as failed, alert also raises an alert and suspend also suspends the job until it is updated again.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(FailurePolicyFail, FailurePolicyAlert, FailurePolicySuspend),
+				},
+			},
+			"notifications": jobNotificationsAttribute(),
 			"oauth": schema.SingleNestedAttribute{
 				Description: "The OAuth config for logging into external service",
 				Optional:    true,
@@ -95,10 +160,16 @@ func (r *jobResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *
 					"authorization_url": schema.StringAttribute{
 						Required:    true,
 						Description: "the full url to start the authorization",
+						Validators: []validator.String{
+							httpURLValidator{},
+						},
 					},
 					"token_url": schema.StringAttribute{
 						Required:    true,
 						Description: "the full URL to fetch the token from",
+						Validators: []validator.String{
+							httpURLValidator{},
+						},
 					},
 					"scope": schema.StringAttribute{
 						Optional:    true,
@@ -107,11 +178,18 @@ func (r *jobResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *
 					"config_prefix": schema.StringAttribute{
 						Required:    true,
 						Description: "the prefix for the configuration variables returned from the token response like 'EXACT_ONLINE_",
+						Validators: []validator.String{
+							envVarNameValidator{},
+						},
 					},
 				},
 			},
 		},
 	}
+
+	for name, attribute := range writeOnlySecretsAttributes(false) {
+		resp.Schema.Attributes[name] = attribute
+	}
 }
 
 type Environment map[string]string
@@ -121,6 +199,9 @@ type Command []string
 // Create creates the resource and sets the initial Terraform state.
 // Create a new resource
 func (r *jobResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_job", "Create")
+	defer endSpan(span, &resp.Diagnostics)
 
 	// Retrieve values from plan
 	var job jobResourceModel
@@ -144,6 +225,19 @@ func (r *jobResource) Create(ctx context.Context, req resource.CreateRequest, re
 		return
 	}
 
+	secretsWO, diags := writeOnlySecrets(ctx, req.Config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if len(secretsWO) > 0 {
+		secrets = mergeSecrets(secrets, secretsWO)
+	}
+	resp.Diagnostics.Append(job.resolveSecretsWOKeys(ctx, secretsWO)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var command []string
 	diags = job.Command.ElementsAs(ctx, &command, false)
 	resp.Diagnostics.Append(diags...)
@@ -163,29 +257,92 @@ func (r *jobResource) Create(ctx context.Context, req resource.CreateRequest, re
 		}
 	}
 
+	resp.Diagnostics.Append(r.resolveImageDigest(ctx, &job)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var resources *datahub.ResourcesConfig
+	if job.Resources != nil {
+		var resourcesDiags diag.Diagnostics
+		resources, resourcesDiags = job.Resources.toAPI()
+		resp.Diagnostics.Append(resourcesDiags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	var retryPolicy *datahub.RetryPolicy
+	if job.RetryPolicy != nil {
+		retryPolicy, diags = job.RetryPolicy.toAPI(ctx)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	jobRequest := datahub.CreateJobRequest{
 		Name:        job.Name.ValueString(),
 		JobType:     "full",
-		Image:       job.Image.ValueString(),
+		Image:       job.deployedImage(),
 		Environment: &environment,
 		Secrets:     &secrets,
 		Command:     &command,
 		OAuth:       oauth,
+		Resources:   resources,
+		RetryPolicy: retryPolicy,
+	}
+
+	if !job.FailurePolicy.IsNull() {
+		failurePolicy := job.FailurePolicy.ValueString()
+		jobRequest.FailurePolicy = &failurePolicy
+	}
+
+	if len(job.SecretRefs) > 0 {
+		secretRefs := secretRefsToAPI(job.SecretRefs)
+		jobRequest.SecretRefs = &secretRefs
+	}
+
+	if len(job.Notifications) > 0 {
+		notifications, diags := jobNotificationsToAPI(ctx, job.Notifications)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		jobRequest.Notifications = &notifications
+	}
+
+	if !job.ImagePullCredentialID.IsNull() {
+		credentialID, err := uuid.Parse(job.ImagePullCredentialID.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error creating job",
+				"Could not parse image_pull_credential_id "+job.ImagePullCredentialID.ValueString()+": "+err.Error(),
+			)
+			return
+		}
+		jobRequest.ImagePullCredentialID = &credentialID
+	}
+
+	client, diags := r.tenants.get(job.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	jobResponse, err := r.client.Job.Create(ctx, jobRequest)
+	jobResponse, err := client.Job.Create(ctx, jobRequest)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error creating job",
-			"Could not create job, unexpected error: "+err.Error(),
-		)
+			"Could not create job",
+			err, path.Root("name"), req.Plan.Schema,
+		)...)
 		return
 	}
 
-	tflog.Debug(ctx, fmt.Sprintf("Job config: %v", job))
-	tflog.Debug(ctx, fmt.Sprintf("Create Job object: %v", jobRequest))
-
 	job.JobId = types.StringValue(jobResponse.ID.String())
+	tflog.Debug(ctx, "Created job", map[string]any{"job_id": job.JobId.ValueString()})
+	setSpanAttributes(ctx, jobIDKey.String(job.JobId.ValueString()))
 
 	// Set state to fully populated data
 	diags = resp.State.Set(ctx, job)
@@ -197,6 +354,10 @@ func (r *jobResource) Create(ctx context.Context, req resource.CreateRequest, re
 
 // Read refreshes the Terraform state with the latest data.
 func (r *jobResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_job", "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
 	// Get current state
 	var state jobResourceModel
 	diags := req.State.Get(ctx, &state)
@@ -205,6 +366,7 @@ func (r *jobResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		return
 	}
 
+	setSpanAttributes(ctx, jobIDKey.String(state.JobId.ValueString()))
 	jobID, err := uuid.Parse(state.JobId.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -214,28 +376,54 @@ func (r *jobResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		return
 	}
 
-	job, err := r.client.Job.Get(ctx, jobID)
+	client, diags := r.tenants.get(state.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	job, err := client.Job.Get(ctx, jobID)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error Reading Datahub Job",
-			"Could not read Datahub job ID "+state.JobId.ValueString()+": "+err.Error(),
-		)
+			"Could not read Datahub job ID "+state.JobId.ValueString(),
+			err, path.Empty(), req.State.Schema,
+		)...)
 		return
 	}
 
 	state.Name = types.StringValue(job.Name)
-	state.Image = types.StringValue(job.Image)
+	if state.PinDigest.ValueBool() {
+		image, digest := splitImageDigest(job.Image)
+		state.Image = types.StringValue(image)
+		state.ImageDigest = types.StringValue(digest)
+		if digest == "" {
+			state.ImageDigest = types.StringNull()
+		}
+	} else {
+		state.Image = types.StringValue(job.Image)
+		state.ImageDigest = types.StringNull()
+	}
+
+	if job.ImagePullCredentialID != nil && *job.ImagePullCredentialID != uuid.Nil {
+		state.ImagePullCredentialID = types.StringValue(job.ImagePullCredentialID.String())
+	} else {
+		state.ImagePullCredentialID = types.StringNull()
+	}
 
 	if len(job.Environment) > 0 {
 		// To support jobs that create environment and secrets themselves we don't consider them changes
 		// This should be enabled by a feature flag in the future so you can use the terraform config to delete keys from the environment and secrets
 		newEnv := dropUntracked(state.Environment, job.Environment)
 
-		state.Environment, diags = types.MapValueFrom(ctx, types.StringType, newEnv)
+		// Keys managed by datahub_job_environment_variable don't make an unset environment empty
+		if len(newEnv) > 0 || !state.Environment.IsNull() {
+			state.Environment, diags = types.MapValueFrom(ctx, types.StringType, newEnv)
 
-		resp.Diagnostics.Append(diags...)
-		if resp.Diagnostics.HasError() {
-			return
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
 		}
 	}
 
@@ -244,7 +432,32 @@ func (r *jobResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		// This should be enabled by a feature flag in the future so you can use the terraform config to delete keys from the environment and secrets
 		newSecrets := dropUntracked(state.Secrets, job.Secrets)
 
-		state.Secrets, diags = types.MapValueFrom(ctx, types.StringType, newSecrets)
+		// Keys managed by datahub_job_secret don't make unset secrets empty
+		if len(newSecrets) > 0 || !state.Secrets.IsNull() {
+			state.Secrets, diags = types.MapValueFrom(ctx, types.StringType, newSecrets)
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+	}
+
+	// Only the keys of secrets_wo are known, a key that went missing is sent again
+	if !state.SecretsWOKeys.IsNull() {
+		var secretsWOKeys []string
+		resp.Diagnostics.Append(state.SecretsWOKeys.ElementsAs(ctx, &secretsWOKeys, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		present := []string{}
+		for _, key := range secretsWOKeys {
+			if _, ok := job.Secrets[key]; ok {
+				present = append(present, key)
+			}
+		}
+
+		state.SecretsWOKeys, diags = types.SetValueFrom(ctx, types.StringType, present)
 		resp.Diagnostics.Append(diags...)
 		if resp.Diagnostics.HasError() {
 			return
@@ -259,7 +472,7 @@ func (r *jobResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		}
 	}
 
-	if job.OAuth != nil && job.OAuth.Application != "" && job.OAuth.Flow != "" && job.OAuth.TokenUrl != "" && job.OAuth.AuthorizationUrl != "" && job.OAuth.ConfigPrefix != ""{
+	if job.OAuth != nil && job.OAuth.Application != "" && job.OAuth.Flow != "" && job.OAuth.TokenUrl != "" && job.OAuth.AuthorizationUrl != "" && job.OAuth.ConfigPrefix != "" {
 		state.OAuth = &jobResourceOauthModel{
 			Application:      types.StringValue(job.OAuth.Application),
 			Flow:             types.StringValue(job.OAuth.Flow),
@@ -269,6 +482,27 @@ func (r *jobResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		}
 	}
 
+	state.Resources = containerResourcesFromAPI(state.Resources, job.Resources)
+
+	state.RetryPolicy, diags = retryPolicyFromAPI(ctx, state.RetryPolicy, job.RetryPolicy)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.SecretRefs = secretRefsFromAPI(job.SecretRefs)
+
+	state.Notifications, diags = jobNotificationsFromAPI(ctx, job.Notifications)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// An unset failure policy equals the default, so only track it when configured
+	if job.FailurePolicy != nil && (!state.FailurePolicy.IsNull() || *job.FailurePolicy != FailurePolicyFail) {
+		state.FailurePolicy = types.StringValue(*job.FailurePolicy)
+	}
+
 	// Set refreshed state
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -279,6 +513,10 @@ func (r *jobResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 
 // Update updates the resource and sets the updated Terraform state on success.
 func (r *jobResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_job", "Update")
+	defer endSpan(span, &resp.Diagnostics)
+
 	// Retrieve values from plan
 	var plan jobResourceModel
 	var state jobResourceModel
@@ -294,6 +532,11 @@ func (r *jobResource) Update(ctx context.Context, req resource.UpdateRequest, re
 		return
 	}
 
+	resp.Diagnostics.Append(r.resolveImageDigest(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	updateReq := datahub.UpdateOptions{}
 	if !plan.Name.Equal(state.Name) {
 		name := plan.Name.ValueString()
@@ -305,11 +548,28 @@ func (r *jobResource) Update(ctx context.Context, req resource.UpdateRequest, re
 		updateReq.JobType = &typeVal
 	}
 
-	if !plan.Image.Equal(state.Image) {
-		image := plan.Image.ValueString()
+	if !plan.Image.Equal(state.Image) || !plan.ImageDigest.Equal(state.ImageDigest) {
+		image := plan.deployedImage()
 		updateReq.Image = &image
 	}
 
+	if !plan.ImagePullCredentialID.Equal(state.ImagePullCredentialID) {
+		// The nil UUID removes the credential from the job
+		credentialID := uuid.Nil
+		if !plan.ImagePullCredentialID.IsNull() {
+			var err error
+			credentialID, err = uuid.Parse(plan.ImagePullCredentialID.ValueString())
+			if err != nil {
+				resp.Diagnostics.AddError(
+					"Error Updating Datahub Job",
+					"Could not parse image_pull_credential_id "+plan.ImagePullCredentialID.ValueString()+": "+err.Error(),
+				)
+				return
+			}
+		}
+		updateReq.ImagePullCredentialID = &credentialID
+	}
+
 	if !plan.Environment.Equal(state.Environment) {
 		var environment map[string]string
 		diags = plan.Environment.ElementsAs(ctx, &environment, false)
@@ -322,7 +582,9 @@ func (r *jobResource) Update(ctx context.Context, req resource.UpdateRequest, re
 
 	}
 
-	if !plan.Secrets.Equal(state.Secrets) {
+	// Write-only secrets can't be compared, they are sent along whenever the
+	// secrets, their keys or their version change
+	if !plan.Secrets.Equal(state.Secrets) || !plan.SecretsWOKeys.Equal(state.SecretsWOKeys) || !plan.SecretsWOVersion.Equal(state.SecretsWOVersion) {
 		var secrets map[string]string
 		diags = plan.Secrets.ElementsAs(ctx, &secrets, false)
 		resp.Diagnostics.Append(diags...)
@@ -330,7 +592,19 @@ func (r *jobResource) Update(ctx context.Context, req resource.UpdateRequest, re
 			return
 		}
 
+		secretsWO, diags := writeOnlySecrets(ctx, req.Config)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		secrets = mergeSecrets(secrets, secretsWO)
 		updateReq.Secrets = &secrets
+
+		resp.Diagnostics.Append(plan.resolveSecretsWOKeys(ctx, secretsWO)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
 	if !plan.Command.Equal(state.Command) {
@@ -361,6 +635,47 @@ func (r *jobResource) Update(ctx context.Context, req resource.UpdateRequest, re
 		}
 	}
 
+	if !plan.Resources.Equal(state.Resources) {
+		resources, resourcesDiags := plan.Resources.toAPI()
+		resp.Diagnostics.Append(resourcesDiags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		updateReq.Resources = resources
+	}
+
+	if !plan.RetryPolicy.Equal(state.RetryPolicy) {
+		updateReq.RetryPolicy, diags = plan.RetryPolicy.toAPI(ctx)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !plan.FailurePolicy.Equal(state.FailurePolicy) {
+		failurePolicy := FailurePolicyFail
+		if !plan.FailurePolicy.IsNull() {
+			failurePolicy = plan.FailurePolicy.ValueString()
+		}
+		updateReq.FailurePolicy = &failurePolicy
+	}
+
+	if !secretRefsEqual(plan.SecretRefs, state.SecretRefs) {
+		secretRefs := secretRefsToAPI(plan.SecretRefs)
+		updateReq.SecretRefs = &secretRefs
+	}
+
+	if !jobNotificationsEqual(plan.Notifications, state.Notifications) {
+		notifications, diags := jobNotificationsToAPI(ctx, plan.Notifications)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		updateReq.Notifications = &notifications
+	}
+
+	setSpanAttributes(ctx, jobIDKey.String(state.JobId.ValueString()))
 	jobID, err := uuid.Parse(state.JobId.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -370,12 +685,42 @@ func (r *jobResource) Update(ctx context.Context, req resource.UpdateRequest, re
 		return
 	}
 
-	_, err = r.client.Job.Update(ctx, jobID, updateReq)
+	client, diags := r.tenants.get(state.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The API replaces the whole map, so keys the job doesn't track, like the
+	// ones of datahub_job_environment_variable and datahub_job_secret, are sent along
+	if updateReq.Environment != nil || updateReq.Secrets != nil {
+		current, err := client.Job.Get(ctx, jobID)
+		if err != nil {
+			resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+				"Error Updating Datahub Job",
+				"Could not read Datahub job ID "+state.JobId.ValueString(),
+				err, path.Empty(), req.Plan.Schema,
+			)...)
+			return
+		}
+
+		if updateReq.Environment != nil {
+			environment := keepUntracked(trackedKeys(state.Environment), *updateReq.Environment, current.Environment)
+			updateReq.Environment = &environment
+		}
+		if updateReq.Secrets != nil {
+			secrets := keepUntracked(trackedKeys(state.Secrets, state.SecretsWOKeys), *updateReq.Secrets, current.Secrets)
+			updateReq.Secrets = &secrets
+		}
+	}
+
+	_, err = client.Job.Update(ctx, jobID, updateReq)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error Updating Datahub Job",
-			"unexpected error: "+err.Error(),
-		)
+			"Could not update Datahub job ID "+state.JobId.ValueString(),
+			err, path.Root("name"), req.Plan.Schema,
+		)...)
 		return
 	}
 
@@ -388,6 +733,10 @@ func (r *jobResource) Update(ctx context.Context, req resource.UpdateRequest, re
 
 // Delete deletes the resource and removes the Terraform state on success.
 func (r *jobResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_job", "Delete")
+	defer endSpan(span, &resp.Diagnostics)
+
 	// Retrieve values from state
 	var state jobResourceModel
 	diags := req.State.Get(ctx, &state)
@@ -396,6 +745,17 @@ func (r *jobResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 		return
 	}
 
+	if state.DeletionProtection.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("deletion_protection"),
+			"Datahub Job Is Protected From Deletion",
+			"The Datahub job "+state.Name.ValueString()+" ("+state.JobId.ValueString()+") cannot be deleted because deletion_protection is true. "+
+				"Set deletion_protection to false and apply that change before destroying or replacing the job.",
+		)
+		return
+	}
+
+	setSpanAttributes(ctx, jobIDKey.String(state.JobId.ValueString()))
 	jobID, err := uuid.Parse(state.JobId.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -405,15 +765,178 @@ func (r *jobResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 		return
 	}
 
+	client, diags := r.tenants.get(state.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Delete existing job
-	err = r.client.Job.Delete(ctx, jobID)
+	err = client.Job.Delete(ctx, jobID)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Error Deleting Datahub Job",
-			"Could not delete job, unexpected error: "+err.Error(),
-		)
+			"Could not delete job",
+			err, path.Empty(), req.State.Schema,
+		)...)
+		return
+	}
+}
+
+// ValidateConfig checks the requests against the limits in the resources
+// block, the retry backoffs, and the environment, secrets and oauth attributes against each other
+// as they all end up as environment variables of the container.
+func (r *jobResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	validateContainerResources(ctx, req.Config, &resp.Diagnostics)
+	validateRetryPolicy(ctx, req.Config, &resp.Diagnostics)
+	validateWriteOnlySecrets(ctx, req.Config, &resp.Diagnostics)
+	validateSecretRefs(ctx, req.Config, &resp.Diagnostics)
+
+	var environment, secrets, secretsWO, secretRefs types.Map
+	var configPrefix types.String
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("environment"), &environment)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("secrets"), &secrets)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("secrets_wo"), &secretsWO)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("secret_refs"), &secretRefs)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("oauth").AtName("config_prefix"), &configPrefix)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for key := range secrets.Elements() {
+		if _, found := environment.Elements()[key]; found {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("secrets").AtMapKey(key),
+				"Conflicting Environment Variable",
+				"The key "+key+" is set in both environment and secrets, it can only be set in one of them.",
+			)
+		}
+	}
+
+	for key := range secretRefs.Elements() {
+		for attribute, values := range map[string]types.Map{"environment": environment, "secrets": secrets, "secrets_wo": secretsWO} {
+			if _, found := values.Elements()[key]; found {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("secret_refs").AtMapKey(key),
+					"Conflicting Environment Variable",
+					"The key "+key+" is set in both "+attribute+" and secret_refs, it can only be set in one of them.",
+				)
+			}
+		}
+	}
+
+	if configPrefix.IsNull() || configPrefix.IsUnknown() || configPrefix.ValueString() == "" {
+		return
+	}
+
+	prefix := configPrefix.ValueString()
+	for attribute, values := range map[string]types.Map{"environment": environment, "secrets": secrets, "secrets_wo": secretsWO, "secret_refs": secretRefs} {
+		for key := range values.Elements() {
+			if strings.HasPrefix(key, prefix) {
+				resp.Diagnostics.AddAttributeError(
+					path.Root(attribute).AtMapKey(key),
+					"Conflicting OAuth Config Prefix",
+					"The key "+key+" starts with oauth.config_prefix "+prefix+" and would clash with the configuration variables returned from the token response.",
+				)
+			}
+		}
+	}
+}
+
+// ModifyPlan resolves the digest of the image when pin_digest is set, so a
+// moved tag shows up in the plan.
+func (r *jobResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do when the resource is being destroyed
+	if req.Plan.Raw.IsNull() {
 		return
 	}
+
+	var pinDigest types.Bool
+	var image types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("pin_digest"), &pinDigest)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("image"), &image)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	imageDigest := types.StringNull()
+	switch {
+	case !pinDigest.ValueBool():
+	case pinDigest.IsUnknown() || image.IsUnknown() || r.imageDigestResolver == nil:
+		imageDigest = types.StringUnknown()
+	default:
+		digest, err := r.imageDigestResolver.Resolve(ctx, image.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("image"),
+				"Unable to Resolve Image Digest",
+				"Could not resolve the digest of image "+image.ValueString()+" for pin_digest: "+err.Error(),
+			)
+			return
+		}
+		imageDigest = types.StringValue(digest)
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("image_digest"), imageDigest)...)
+
+	// Write-only values are only available in the configuration
+	var secretsWO types.Map
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("secrets_wo"), &secretsWO)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretsWOKeys := types.SetNull(types.StringType)
+	switch {
+	case secretsWO.IsUnknown():
+		secretsWOKeys = types.SetUnknown(types.StringType)
+	case !secretsWO.IsNull():
+		var diags diag.Diagnostics
+		secretsWOKeys, diags = types.SetValueFrom(ctx, types.StringType, maps.Keys(secretsWO.Elements()))
+		resp.Diagnostics.Append(diags...)
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("secrets_wo_keys"), secretsWOKeys)...)
+}
+
+// resolveImageDigest resolves an image digest that was still unknown during
+// plan, because the image itself wasn't known yet.
+func (r *jobResource) resolveImageDigest(ctx context.Context, job *jobResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !job.ImageDigest.IsUnknown() {
+		return diags
+	}
+
+	if !job.PinDigest.ValueBool() {
+		job.ImageDigest = types.StringNull()
+		return diags
+	}
+
+	// ModifyPlan leaves the digest unknown while the provider isn't
+	// configured, by now it must be
+	if r.imageDigestResolver == nil {
+		diags.AddAttributeError(
+			path.Root("pin_digest"),
+			"Unable to Resolve Image Digest",
+			"Could not resolve the digest of image "+job.Image.ValueString()+" for pin_digest, the provider is not configured. "+
+				"This is an error in the provider, please report it to the provider developers.",
+		)
+		return diags
+	}
+
+	digest, err := r.imageDigestResolver.Resolve(ctx, job.Image.ValueString())
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("image"),
+			"Unable to Resolve Image Digest",
+			"Could not resolve the digest of image "+job.Image.ValueString()+" for pin_digest: "+err.Error(),
+		)
+		return diags
+	}
+
+	job.ImageDigest = types.StringValue(digest)
+	return diags
 }
 
 func (r *jobResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
@@ -421,23 +944,85 @@ func (r *jobResource) Configure(_ context.Context, req resource.ConfigureRequest
 		return
 	}
 
-	r.client = req.ProviderData.(*datahub.DatahubClient)
+	providerData := req.ProviderData.(*datahubProviderData)
+	r.tenants = providerData.tenants
+	r.imageDigestResolver = providerData.imageDigestResolver
 }
 
 func (r *jobResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	// Retrieve import ID and save to id attribute
-	resource.ImportStatePassthroughID(ctx, path.Root("job_id"), req, resp)
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_job", "ImportState")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// The ID is the job ID, or <act_as_client_id>/<job_id> for a job of
+	// another client
+	actAsClientID, jobID, found := strings.Cut(req.ID, "/")
+	if !found {
+		resource.ImportStatePassthroughID(ctx, path.Root("job_id"), req, resp)
+		return
+	}
+	if actAsClientID == "" || jobID == "" {
+		resp.Diagnostics.AddError(
+			"Unexpected Import Identifier",
+			"Expected the job ID or <act_as_client_id>/<job_id>, got: "+req.ID,
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("act_as_client_id"), actAsClientID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("job_id"), jobID)...)
 }
 
 type jobResourceModel struct {
-	JobId       types.String           `tfsdk:"job_id"`
-	Name        types.String           `tfsdk:"name"`
-	Type        types.String           `tfsdk:"type"`
-	Image       types.String           `tfsdk:"image"`
-	Environment types.Map              `tfsdk:"environment"`
-	Secrets     types.Map              `tfsdk:"secrets"`
-	Command     types.List             `tfsdk:"command"`
-	OAuth       *jobResourceOauthModel `tfsdk:"oauth"`
+	JobId                 types.String              `tfsdk:"job_id"`
+	ActAsClientID         types.String              `tfsdk:"act_as_client_id"`
+	Name                  types.String              `tfsdk:"name"`
+	Type                  types.String              `tfsdk:"type"`
+	Image                 types.String              `tfsdk:"image"`
+	PinDigest             types.Bool                `tfsdk:"pin_digest"`
+	ImageDigest           types.String              `tfsdk:"image_digest"`
+	ImagePullCredentialID types.String              `tfsdk:"image_pull_credential_id"`
+	Environment           types.Map                 `tfsdk:"environment"`
+	Secrets               types.Map                 `tfsdk:"secrets"`
+	SecretsWO             types.Map                 `tfsdk:"secrets_wo"`
+	SecretsWOVersion      types.Int64               `tfsdk:"secrets_wo_version"`
+	SecretsWOKeys         types.Set                 `tfsdk:"secrets_wo_keys"`
+	SecretRefs            map[string]secretRefModel `tfsdk:"secret_refs"`
+	Command               types.List                `tfsdk:"command"`
+	DeletionProtection    types.Bool                `tfsdk:"deletion_protection"`
+	Resources             *containerResourcesModel  `tfsdk:"resources"`
+	RetryPolicy           *retryPolicyModel         `tfsdk:"retry_policy"`
+	FailurePolicy         types.String              `tfsdk:"failure_policy"`
+	Notifications         []jobNotificationModel    `tfsdk:"notifications"`
+	OAuth                 *jobResourceOauthModel    `tfsdk:"oauth"`
+}
+
+// resolveSecretsWOKeys sets secrets_wo_keys when it was still unknown
+// during plan, because secrets_wo itself wasn't known yet.
+func (m *jobResourceModel) resolveSecretsWOKeys(ctx context.Context, secretsWO map[string]string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !m.SecretsWOKeys.IsUnknown() {
+		return diags
+	}
+
+	if len(secretsWO) == 0 {
+		m.SecretsWOKeys = types.SetNull(types.StringType)
+		return diags
+	}
+
+	m.SecretsWOKeys, diags = types.SetValueFrom(ctx, types.StringType, maps.Keys(secretsWO))
+	return diags
+}
+
+// deployedImage returns the image reference sent to the API, pinned to the
+// image digest when pin_digest is set.
+func (m jobResourceModel) deployedImage() string {
+	if !m.PinDigest.ValueBool() || m.ImageDigest.IsNull() || m.ImageDigest.IsUnknown() {
+		return m.Image.ValueString()
+	}
+
+	image, _ := splitImageDigest(m.Image.ValueString())
+	return image + "@" + m.ImageDigest.ValueString()
 }
 
 type jobResourceOauthModel struct {
@@ -481,3 +1066,38 @@ func dropUntracked(state types.Map, new map[string]string) map[string]string {
 
 	return new
 }
+
+// trackedKeys returns the keys of values together with the extra keys.
+func trackedKeys(values types.Map, extraKeys ...types.Set) map[string]bool {
+	tracked := map[string]bool{}
+	for key := range values.Elements() {
+		tracked[key] = true
+	}
+	for _, keys := range extraKeys {
+		for _, element := range keys.Elements() {
+			if key, ok := element.(types.String); ok {
+				tracked[key.ValueString()] = true
+			}
+		}
+	}
+	return tracked
+}
+
+// keepUntracked adds the keys of current that aren't tracked to new, so
+// replacing the map doesn't delete keys managed outside of the job.
+func keepUntracked(tracked map[string]bool, new map[string]string, current map[string]string) map[string]string {
+	if new == nil {
+		new = map[string]string{}
+	}
+
+	for key, value := range current {
+		if tracked[key] {
+			continue
+		}
+		if _, ok := new[key]; !ok {
+			new[key] = value
+		}
+	}
+
+	return new
+}
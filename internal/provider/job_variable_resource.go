@@ -0,0 +1,407 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &jobVariableResource{}
+	_ resource.ResourceWithConfigure   = &jobVariableResource{}
+	_ resource.ResourceWithImportState = &jobVariableResource{}
+)
+
+// NewJobEnvironmentVariableResource is a helper function to simplify the provider implementation.
+func NewJobEnvironmentVariableResource() resource.Resource {
+	return &jobVariableResource{secret: false}
+}
+
+// NewJobSecretResource is a helper function to simplify the provider implementation.
+func NewJobSecretResource() resource.Resource {
+	return &jobVariableResource{secret: true}
+}
+
+// jobVariableResource manages a single environment variable or secret of a
+// job, next to the ones in the environment and secrets maps of datahub_job.
+type jobVariableResource struct {
+	tenants *tenantClients
+
+	// secret selects the secrets of the job instead of its environment.
+	secret bool
+}
+
+// kind returns the name of what the resource manages, for use in messages.
+func (r *jobVariableResource) kind() string {
+	if r.secret {
+		return "secret"
+	}
+	return "environment variable"
+}
+
+// title returns kind in title case, for use in diagnostic summaries.
+func (r *jobVariableResource) title() string {
+	if r.secret {
+		return "Secret"
+	}
+	return "Environment Variable"
+}
+
+// values returns the environment variables or secrets of the job.
+func (r *jobVariableResource) values(job *datahub.Job) map[string]string {
+	if r.secret {
+		return job.Secrets
+	}
+	return job.Environment
+}
+
+func (r *jobVariableResource) set(ctx context.Context, client *datahub.DatahubClient, jobID uuid.UUID, key string, value string) error {
+	if r.secret {
+		return client.Job.SetSecret(ctx, jobID, key, value)
+	}
+	return client.Job.SetEnvironmentVariable(ctx, jobID, key, value)
+}
+
+func (r *jobVariableResource) delete(ctx context.Context, client *datahub.DatahubClient, jobID uuid.UUID, key string) error {
+	if r.secret {
+		return client.Job.DeleteSecret(ctx, jobID, key)
+	}
+	return client.Job.DeleteEnvironmentVariable(ctx, jobID, key)
+}
+
+// resourceType returns the full resource type name, used for tracing.
+func (r *jobVariableResource) resourceType() string {
+	if r.secret {
+		return "datahub_job_secret"
+	}
+	return "datahub_job_environment_variable"
+}
+
+// Metadata returns the resource type name.
+func (r *jobVariableResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	if r.secret {
+		resp.TypeName = req.ProviderTypeName + "_job_secret"
+	} else {
+		resp.TypeName = req.ProviderTypeName + "_job_environment_variable"
+	}
+}
+
+// Schema defines the schema for the resource.
+func (r *jobVariableResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	mapName := "environment"
+	if r.secret {
+		mapName = "secrets"
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Manages a single " + r.kind() + " of a Datahub job. " +
+			"The key must not also be set in the " + mapName + " attribute of the datahub_job, keys managed by this resource are ignored there otherwise.",
+		Attributes: map[string]schema.Attribute{
+			"act_as_client_id": actAsClientIDAttribute("job of the " + r.kind()),
+			"job_id": schema.StringAttribute{
+				Description: "job_id of the datahub_job the " + r.kind() + " belongs to.",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					uuidValidator(),
+				},
+			},
+			"key": schema.StringAttribute{
+				Description: "Name of the " + r.kind() + ".",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					envVarNameValidator{},
+				},
+			},
+			"value": schema.StringAttribute{
+				Description: "Value of the " + r.kind() + ".",
+				Required:    true,
+				Sensitive:   r.secret,
+			},
+		},
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *jobVariableResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, r.resourceType(), "Create")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from plan
+	var plan jobVariableResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.tenants.get(plan.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	setSpanAttributes(ctx, jobIDKey.String(plan.JobID.ValueString()))
+	jobID, err := uuid.Parse(plan.JobID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating job "+r.kind(),
+			"Could not parse job ID "+plan.JobID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// Refuse to take over a key that is already set, it might be managed
+	// by the datahub_job itself
+	job, err := client.Job.Get(ctx, jobID)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error creating job "+r.kind(),
+			"Could not read Datahub job ID "+plan.JobID.ValueString(),
+			err, path.Empty(), req.Plan.Schema,
+		)...)
+		return
+	}
+	if _, ok := r.values(job)[plan.Key.ValueString()]; ok {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("key"),
+			"Job "+r.title()+" Already Exists",
+			"The job "+plan.JobID.ValueString()+" already has a "+r.kind()+" "+plan.Key.ValueString()+". "+
+				"Remove it from the datahub_job or import it with the ID "+jobVariableImportID(plan)+".",
+		)
+		return
+	}
+
+	err = r.set(ctx, client, jobID, plan.Key.ValueString(), plan.Value.ValueString())
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error creating job "+r.kind(),
+			"Could not create job "+r.kind(),
+			err, path.Root("key"), req.Plan.Schema,
+		)...)
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *jobVariableResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, r.resourceType(), "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Get current state
+	var state jobVariableResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.tenants.get(state.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	setSpanAttributes(ctx, jobIDKey.String(state.JobID.ValueString()))
+	jobID, err := uuid.Parse(state.JobID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading Datahub Job "+r.title(),
+			"Could not parse job ID "+state.JobID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	job, err := client.Job.Get(ctx, jobID)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Reading Datahub Job "+r.title(),
+			"Could not read Datahub job ID "+state.JobID.ValueString(),
+			err, path.Empty(), req.State.Schema,
+		)...)
+		return
+	}
+
+	value, ok := r.values(job)[state.Key.ValueString()]
+	if !ok {
+		// Deleted outside of Terraform
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	state.Value = types.StringValue(value)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *jobVariableResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, r.resourceType(), "Update")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from plan
+	var plan jobVariableResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.tenants.get(plan.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	setSpanAttributes(ctx, jobIDKey.String(plan.JobID.ValueString()))
+	jobID, err := uuid.Parse(plan.JobID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Updating Datahub Job "+r.title(),
+			"Could not parse job ID "+plan.JobID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	err = r.set(ctx, client, jobID, plan.Key.ValueString(), plan.Value.ValueString())
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Updating Datahub Job "+r.title(),
+			"Could not update job "+r.kind()+" "+plan.Key.ValueString(),
+			err, path.Root("key"), req.Plan.Schema,
+		)...)
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *jobVariableResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, r.resourceType(), "Delete")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from state
+	var state jobVariableResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.tenants.get(state.ActAsClientID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	setSpanAttributes(ctx, jobIDKey.String(state.JobID.ValueString()))
+	jobID, err := uuid.Parse(state.JobID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Deleting Datahub Job "+r.title(),
+			"Could not parse job ID "+state.JobID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	err = r.delete(ctx, client, jobID, state.Key.ValueString())
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Deleting Datahub Job "+r.title(),
+			"Could not delete job "+r.kind(),
+			err, path.Empty(), req.State.Schema,
+		)...)
+		return
+	}
+}
+
+func (r *jobVariableResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.tenants = req.ProviderData.(*datahubProviderData).tenants
+}
+
+// ImportState imports the resource by an ID of the form <job_id>/<key>, or
+// <act_as_client_id>/<job_id>/<key> for a job of another client.
+func (r *jobVariableResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, r.resourceType(), "ImportState")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Keys are environment variable names, so they never contain a slash
+	var actAsClientID, jobID, key string
+	parts := strings.Split(req.ID, "/")
+	switch len(parts) {
+	case 2:
+		jobID, key = parts[0], parts[1]
+	case 3:
+		actAsClientID, jobID, key = parts[0], parts[1], parts[2]
+	}
+	if jobID == "" || key == "" || len(parts) == 3 && actAsClientID == "" {
+		resp.Diagnostics.AddError(
+			"Unexpected Import Identifier",
+			"Expected an import identifier of the form <job_id>/<key> or <act_as_client_id>/<job_id>/<key>, got "+req.ID+".",
+		)
+		return
+	}
+
+	if actAsClientID != "" {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("act_as_client_id"), actAsClientID)...)
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("job_id"), jobID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("key"), key)...)
+}
+
+// jobVariableImportID returns the import ID of the environment variable or
+// secret.
+func jobVariableImportID(model jobVariableResourceModel) string {
+	id := model.JobID.ValueString() + "/" + model.Key.ValueString()
+	if model.ActAsClientID.ValueString() != "" {
+		id = model.ActAsClientID.ValueString() + "/" + id
+	}
+	return id
+}
+
+type jobVariableResourceModel struct {
+	ActAsClientID types.String `tfsdk:"act_as_client_id"`
+	JobID         types.String `tfsdk:"job_id"`
+	Key           types.String `tfsdk:"key"`
+	Value         types.String `tfsdk:"value"`
+}
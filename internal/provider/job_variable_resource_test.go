@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestJobVariableImportState(t *testing.T) {
+	tests := []struct {
+		id                string
+		wantActAsClientID string
+		wantJobID         string
+		wantKey           string
+		wantErr           bool
+	}{
+		{id: "job/DB_HOST", wantJobID: "job", wantKey: "DB_HOST"},
+		{id: "customer/job/DB_HOST", wantActAsClientID: "customer", wantJobID: "job", wantKey: "DB_HOST"},
+		{id: "job", wantErr: true},
+		{id: "job/", wantErr: true},
+		{id: "/job/DB_HOST", wantErr: true},
+		{id: "customer/job/DB_HOST/extra", wantErr: true},
+	}
+
+	ctx := context.Background()
+	r := NewJobSecretResource()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			resp := &resource.ImportStateResponse{
+				State: tfsdk.State{
+					Schema: schemaResp.Schema,
+					Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+				},
+			}
+			r.(resource.ResourceWithImportState).ImportState(ctx, resource.ImportStateRequest{ID: tt.id}, resp)
+
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("ImportState(%q) diagnostics = %v, want error %t", tt.id, resp.Diagnostics, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var model jobVariableResourceModel
+			resp.Diagnostics.Append(resp.State.Get(ctx, &model)...)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("reading state: %v", resp.Diagnostics)
+			}
+			if model.ActAsClientID.ValueString() != tt.wantActAsClientID || model.JobID.ValueString() != tt.wantJobID || model.Key.ValueString() != tt.wantKey {
+				t.Errorf("ImportState(%q) = %s/%s/%s, want %s/%s/%s", tt.id,
+					model.ActAsClientID.ValueString(), model.JobID.ValueString(), model.Key.ValueString(),
+					tt.wantActAsClientID, tt.wantJobID, tt.wantKey)
+			}
+			if got := jobVariableImportID(model); got != tt.id {
+				t.Errorf("jobVariableImportID() = %q, want %q", got, tt.id)
+			}
+		})
+	}
+}
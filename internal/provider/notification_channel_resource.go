@@ -0,0 +1,367 @@
+package provider
+
+import (
+	"context"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	NotificationChannelTypeWebhook = "webhook"
+	NotificationChannelTypeEmail   = "email"
+	NotificationChannelTypeTeams   = "teams"
+	NotificationChannelTypeSlack   = "slack"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                   = &notificationChannelResource{}
+	_ resource.ResourceWithConfigure      = &notificationChannelResource{}
+	_ resource.ResourceWithImportState    = &notificationChannelResource{}
+	_ resource.ResourceWithValidateConfig = &notificationChannelResource{}
+)
+
+// NewNotificationChannelResource is a helper function to simplify the provider implementation.
+func NewNotificationChannelResource() resource.Resource {
+	return &notificationChannelResource{}
+}
+
+// notificationChannelResource is the resource implementation.
+type notificationChannelResource struct {
+	client *datahub.DatahubClient
+}
+
+// Metadata returns the resource type name.
+func (r *notificationChannelResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_notification_channel"
+}
+
+// Schema defines the schema for the resource.
+func (r *notificationChannelResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a channel Datahub sends job notifications to, subscribed to with the notifications attribute of datahub_job.",
+		Attributes: map[string]schema.Attribute{
+			"channel_id": schema.StringAttribute{
+				Description: "Identifier of the notification channel.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Description: "Name of the notification channel.",
+				Required:    true,
+			},
+			"type": schema.StringAttribute{
+				Description: "Channel type: webhook, email, teams or slack",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.OneOf(
+						NotificationChannelTypeWebhook,
+						NotificationChannelTypeEmail,
+						NotificationChannelTypeTeams,
+						NotificationChannelTypeSlack,
+					),
+				},
+			},
+			"url": schema.StringAttribute{
+				Description: "URL notifications are posted to, required for webhook, teams and slack channels",
+				Optional:    true,
+				Sensitive:   true,
+				Validators: []validator.String{
+					httpURLValidator{},
+				},
+			},
+			"hmac_secret": schema.StringAttribute{
+				Description: "Secret used to sign webhook payloads with HMAC-SHA256, only for webhook channels. The API never returns it, so changes made outside Terraform are not detected.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"email_addresses": schema.ListAttribute{
+				Description: "Addresses notifications are mailed to, required for email channels",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
+		},
+	}
+}
+
+// ValidateConfig checks that the attributes required by the channel type are set.
+func (r *notificationChannelResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config notificationChannelResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.Type.IsUnknown() {
+		return
+	}
+
+	channelType := config.Type.ValueString()
+	if channelType == NotificationChannelTypeEmail {
+		if config.EmailAddresses.IsNull() || (!config.EmailAddresses.IsUnknown() && len(config.EmailAddresses.Elements()) == 0) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("email_addresses"),
+				"Missing Email Addresses",
+				"At least one email address is required for email channels.",
+			)
+		}
+		if !config.URL.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("url"), "Invalid Attribute Combination", "url can't be set for email channels.")
+		}
+	} else {
+		if config.URL.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("url"),
+				"Missing URL",
+				"A url is required for "+channelType+" channels.",
+			)
+		}
+		if !config.EmailAddresses.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("email_addresses"), "Invalid Attribute Combination", "email_addresses can only be set for email channels.")
+		}
+	}
+
+	if channelType != NotificationChannelTypeWebhook && !config.HMACSecret.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("hmac_secret"), "Invalid Attribute Combination", "hmac_secret can only be set for webhook channels.")
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *notificationChannelResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_notification_channel", "Create")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from plan
+	var plan notificationChannelResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	channelRequest, diags := plan.toAPI(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	channel, err := r.client.NotificationChannel.Create(ctx, channelRequest)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error creating notification channel",
+			"Could not create notification channel",
+			err, path.Root("name"), req.Plan.Schema,
+		)...)
+		return
+	}
+
+	plan.ChannelID = types.StringValue(channel.ID.String())
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *notificationChannelResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_notification_channel", "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Get current state
+	var state notificationChannelResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	channelID, err := uuid.Parse(state.ChannelID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading Datahub Notification Channel",
+			"Could not parse channel ID "+state.ChannelID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	channel, err := r.client.NotificationChannel.Get(ctx, channelID)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Reading Datahub Notification Channel",
+			"Could not read Datahub notification channel ID "+state.ChannelID.ValueString(),
+			err, path.Empty(), req.State.Schema,
+		)...)
+		return
+	}
+
+	state.Name = types.StringValue(channel.Name)
+	state.Type = types.StringValue(channel.Type)
+
+	if channel.URL != "" {
+		state.URL = types.StringValue(channel.URL)
+	} else {
+		state.URL = types.StringNull()
+	}
+
+	if len(channel.EmailAddresses) > 0 {
+		state.EmailAddresses, diags = types.ListValueFrom(ctx, types.StringType, channel.EmailAddresses)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	} else {
+		state.EmailAddresses = types.ListNull(types.StringType)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *notificationChannelResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_notification_channel", "Update")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from plan
+	var plan notificationChannelResourceModel
+	var state notificationChannelResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	channelID, err := uuid.Parse(state.ChannelID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Updating Datahub Notification Channel",
+			"Could not parse channel ID "+state.ChannelID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	channelRequest, diags := plan.toAPI(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, err = r.client.NotificationChannel.Update(ctx, channelID, channelRequest)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Updating Datahub Notification Channel",
+			"Could not update Datahub notification channel ID "+state.ChannelID.ValueString(),
+			err, path.Root("name"), req.Plan.Schema,
+		)...)
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *notificationChannelResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_notification_channel", "Delete")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from state
+	var state notificationChannelResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	channelID, err := uuid.Parse(state.ChannelID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Deleting Datahub Notification Channel",
+			"Could not parse channel ID "+state.ChannelID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	err = r.client.NotificationChannel.Delete(ctx, channelID)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Deleting Datahub Notification Channel",
+			"Could not delete notification channel",
+			err, path.Empty(), req.State.Schema,
+		)...)
+		return
+	}
+}
+
+func (r *notificationChannelResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.client = req.ProviderData.(*datahubProviderData).client
+}
+
+func (r *notificationChannelResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_notification_channel", "ImportState")
+	defer endSpan(span, &resp.Diagnostics)
+
+	resource.ImportStatePassthroughID(ctx, path.Root("channel_id"), req, resp)
+}
+
+type notificationChannelResourceModel struct {
+	ChannelID      types.String `tfsdk:"channel_id"`
+	Name           types.String `tfsdk:"name"`
+	Type           types.String `tfsdk:"type"`
+	URL            types.String `tfsdk:"url"`
+	HMACSecret     types.String `tfsdk:"hmac_secret"`
+	EmailAddresses types.List   `tfsdk:"email_addresses"`
+}
+
+func (m notificationChannelResourceModel) toAPI(ctx context.Context) (datahub.NotificationChannelRequest, diag.Diagnostics) {
+	channelRequest := datahub.NotificationChannelRequest{
+		Name:       m.Name.ValueString(),
+		Type:       m.Type.ValueString(),
+		URL:        m.URL.ValueString(),
+		HMACSecret: m.HMACSecret.ValueString(),
+	}
+
+	diags := m.EmailAddresses.ElementsAs(ctx, &channelRequest.EmailAddresses, false)
+	return channelRequest, diags
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -48,6 +49,10 @@ func (d *oauthDataSource) Schema(_ context.Context, _ datasource.SchemaRequest,
 
 // Read refreshes the Terraform state with the latest data.
 func (d *oauthDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_oauth_url", "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
 	var config oauthDataSourceModel
 
 	diags := req.Config.Get(ctx, &config)
@@ -67,10 +72,11 @@ func (d *oauthDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 
 	oauthResponse, err := d.client.Job.GetOAuthRedirect(ctx, jobID)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
 			"Unable to Get OAuth redirect url for this job",
-			err.Error(),
-		)
+			"Could not get the OAuth redirect URL of job ID "+config.JobID.ValueString(),
+			err, path.Empty(), req.Config.Schema,
+		)...)
 		return
 	}
 
@@ -90,7 +96,7 @@ func (d *oauthDataSource) Configure(_ context.Context, req datasource.ConfigureR
 		return
 	}
 
-	d.client = req.ProviderData.(*datahub.DatahubClient)
+	d.client = req.ProviderData.(*datahubProviderData).client
 }
 
 // oauthDataSourceModel maps the data source schema data.
@@ -0,0 +1,261 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenExchangeGrantType is the OAuth 2.0 token exchange grant of RFC 8693.
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	// jwtTokenType identifies the federated token as a JWT.
+	jwtTokenType = "urn:ietf:params:oauth:token-type:jwt"
+
+	// accessTokenType requests a Datahub access token in return.
+	accessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+
+	// tokenExchangePath is the path of the token endpoint below base_url.
+	// The SDK doesn't export its endpoints, so this path, like
+	// callerIdentityPath, is part of the API contract the provider relies on,
+	// listed in docs/index.md.
+	tokenExchangePath = "/oauth/token"
+
+	// tokenRefreshMargin is how long before expiry an access token is
+	// exchanged again, so requests never go out with an expired token.
+	tokenRefreshMargin = time.Minute
+
+	// defaultTokenLifetime is assumed for access tokens without expires_in,
+	// short enough to not outlive the actual token by much.
+	defaultTokenLifetime = 5 * time.Minute
+)
+
+// oidcTokenSource exchanges a federated OIDC token, like the ID tokens of
+// GitHub Actions and Azure DevOps, for a Datahub access token. The access
+// token is cached and exchanged again shortly before it expires, reading a
+// fresh federated token, so long applies keep working.
+type oidcTokenSource struct {
+	// httpClient reaches the Datahub token endpoint, like every request to
+	// the Datahub API.
+	httpClient *http.Client
+
+	// issuerHTTPClient requests the federated token from the CI system.
+	issuerHTTPClient *http.Client
+
+	// tokenURL is the Datahub token endpoint.
+	tokenURL string
+
+	// clientID optionally selects the Datahub client the token is for.
+	clientID string
+
+	// token, tokenFile and requestURL are the sources of the federated
+	// token, the first one set is used.
+	token        string
+	tokenFile    string
+	requestURL   string
+	requestToken string
+
+	mu      sync.Mutex
+	current grantedToken
+}
+
+// Token returns a valid Datahub access token, exchanging a new federated
+// token when the cached one is about to expire.
+func (s *oidcTokenSource) Token(ctx context.Context) (string, error) {
+	token, err := s.grant(ctx)
+	return token.value, err
+}
+
+// grant returns the valid Datahub access token with its expiry and scopes.
+func (s *oidcTokenSource) grant(ctx context.Context) (grantedToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current.fresh() {
+		return s.current, nil
+	}
+
+	federatedToken, err := s.federatedToken(ctx)
+	if err != nil {
+		return grantedToken{}, err
+	}
+
+	token, err := s.exchange(ctx, federatedToken)
+	if err != nil {
+		return grantedToken{}, err
+	}
+
+	s.current = token
+	return s.current, nil
+}
+
+// federatedToken returns the OIDC token to exchange, read again on every
+// exchange as CI tokens are short-lived.
+func (s *oidcTokenSource) federatedToken(ctx context.Context) (string, error) {
+	switch {
+	case s.token != "":
+		return s.token, nil
+	case s.tokenFile != "":
+		data, err := os.ReadFile(s.tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("reading OIDC token file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case s.requestURL != "":
+		return s.requestFederatedToken(ctx)
+	default:
+		return "", errors.New("no OIDC token source configured")
+	}
+}
+
+// requestFederatedToken requests an ID token from the CI system. GitHub
+// Actions answers a GET with {"value": ...}, Azure DevOps expects a POST to
+// its oidctoken endpoint and answers with {"oidcToken": ...}.
+func (s *oidcTokenSource) requestFederatedToken(ctx context.Context) (string, error) {
+	requestURL, err := url.Parse(s.requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid OIDC request URL: %w", err)
+	}
+
+	method := http.MethodGet
+	var body io.Reader
+	if strings.HasSuffix(strings.ToLower(requestURL.Path), "/oidctoken") {
+		method = http.MethodPost
+		body = strings.NewReader("{}")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL.String(), body)
+	if err != nil {
+		return "", err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.requestToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.requestToken)
+	}
+
+	resp, err := s.issuerHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting OIDC token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting OIDC token: unexpected status %s", resp.Status)
+	}
+
+	var token struct {
+		Value     string `json:"value"`
+		OIDCToken string `json:"oidcToken"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding OIDC token response: %w", err)
+	}
+
+	federatedToken := firstNonEmpty(token.Value, token.OIDCToken)
+	if federatedToken == "" {
+		return "", errors.New("OIDC token response contains no token")
+	}
+	return federatedToken, nil
+}
+
+// exchange trades the federated token for a Datahub access token.
+func (s *oidcTokenSource) exchange(ctx context.Context, federatedToken string) (grantedToken, error) {
+	form := url.Values{
+		"grant_type":           {tokenExchangeGrantType},
+		"subject_token":        {federatedToken},
+		"subject_token_type":   {jwtTokenType},
+		"requested_token_type": {accessTokenType},
+	}
+	if s.clientID != "" {
+		form.Set("client_id", s.clientID)
+	}
+
+	return requestAccessToken(ctx, s.httpClient, s.tokenURL, form)
+}
+
+// grantedToken is an access token of the Datahub token endpoint.
+type grantedToken struct {
+	value     string
+	expiresAt time.Time
+
+	// scopes are the scopes granted to the token, empty when the token
+	// endpoint didn't list them.
+	scopes []string
+}
+
+// fresh reports whether the token can still be used for a while.
+func (t grantedToken) fresh() bool {
+	return t.value != "" && time.Now().Add(tokenRefreshMargin).Before(t.expiresAt)
+}
+
+// requestAccessToken posts the form to the Datahub token endpoint and returns
+// the access token.
+func requestAccessToken(ctx context.Context, httpClient *http.Client, tokenURL string, form url.Values) (grantedToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return grantedToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return grantedToken{}, fmt.Errorf("exchanging token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return grantedToken{}, fmt.Errorf("reading token exchange response: %w", err)
+	}
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Scope            string `json:"scope"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil && resp.StatusCode == http.StatusOK {
+		return grantedToken{}, fmt.Errorf("decoding token exchange response: %w", err)
+	}
+
+	// The status is kept, so a rejected secret can be told apart from other
+	// failures
+	if resp.StatusCode != http.StatusOK {
+		apiErr := parseAPIError(resp.StatusCode, resp.Header, body)
+		if token.Error != "" {
+			apiErr.Code = token.Error
+			apiErr.Message = firstNonEmpty(token.ErrorDescription, token.Error)
+		}
+		apiErr.Message = "exchanging token: " + firstNonEmpty(apiErr.Message, "unexpected status "+resp.Status)
+		return grantedToken{}, apiErr
+	}
+	if token.AccessToken == "" {
+		return grantedToken{}, errors.New("token exchange response contains no access_token")
+	}
+
+	// Without a lifetime the token would never be fresh, and every request
+	// would exchange a new one
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+
+	return grantedToken{
+		value:     token.AccessToken,
+		expiresAt: time.Now().Add(lifetime),
+		scopes:    strings.Fields(token.Scope),
+	}, nil
+}
@@ -0,0 +1,293 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testTokenEndpoint is a stand-in for the Datahub token endpoint that records
+// the forms it receives.
+type testTokenEndpoint struct {
+	// expiresIn is returned as expires_in, left out when 0.
+	expiresIn int
+
+	// status is the status of the response, 200 when 0.
+	status int
+
+	mu    sync.Mutex
+	forms []url.Values
+}
+
+func (e *testTokenEndpoint) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+			t.Errorf("token request = %s with Content-Type %q, want a form POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parsing token request: %v", err)
+		}
+
+		e.mu.Lock()
+		e.forms = append(e.forms, r.PostForm)
+		count := len(e.forms)
+		e.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if e.status != 0 {
+			w.WriteHeader(e.status)
+			fmt.Fprint(w, `{"error": "invalid_grant", "error_description": "token expired"}`)
+			return
+		}
+		if e.expiresIn != 0 {
+			fmt.Fprintf(w, `{"access_token": "access-%d", "expires_in": %d, "scope": "jobs:write"}`, count, e.expiresIn)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "access-%d"}`, count)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// subjectTokens returns the subject_token of every exchange.
+func (e *testTokenEndpoint) subjectTokens() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var tokens []string
+	for _, form := range e.forms {
+		tokens = append(tokens, form.Get("subject_token"))
+	}
+	return tokens
+}
+
+func TestOIDCTokenSourceExchangeForm(t *testing.T) {
+	endpoint := &testTokenEndpoint{expiresIn: 3600}
+	server := endpoint.serve(t)
+
+	source := &oidcTokenSource{
+		httpClient: server.Client(),
+		tokenURL:   server.URL + tokenExchangePath,
+		clientID:   "00000000-0000-0000-0000-000000000001",
+		token:      "federated-jwt",
+	}
+
+	accessToken, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if accessToken != "access-1" {
+		t.Errorf("Token() = %q, want %q", accessToken, "access-1")
+	}
+
+	want := url.Values{
+		"grant_type":           {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_token":        {"federated-jwt"},
+		"subject_token_type":   {"urn:ietf:params:oauth:token-type:jwt"},
+		"requested_token_type": {"urn:ietf:params:oauth:token-type:access_token"},
+		"client_id":            {"00000000-0000-0000-0000-000000000001"},
+	}
+	if got := endpoint.forms[0]; got.Encode() != want.Encode() {
+		t.Errorf("exchange form = %v, want %v", got, want)
+	}
+}
+
+func TestOIDCTokenSourceCachesAccessToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn int
+	}{
+		{"with expires_in", 3600},
+		{"without expires_in", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := &testTokenEndpoint{expiresIn: tt.expiresIn}
+			server := endpoint.serve(t)
+
+			source := &oidcTokenSource{
+				httpClient: server.Client(),
+				tokenURL:   server.URL + tokenExchangePath,
+				token:      "federated-jwt",
+			}
+
+			for i := 0; i < 3; i++ {
+				accessToken, err := source.Token(context.Background())
+				if err != nil {
+					t.Fatalf("Token() error = %v", err)
+				}
+				if accessToken != "access-1" {
+					t.Errorf("Token() = %q, want the cached %q", accessToken, "access-1")
+				}
+			}
+			if len(endpoint.forms) != 1 {
+				t.Errorf("token endpoint called %d times, want once", len(endpoint.forms))
+			}
+		})
+	}
+}
+
+func TestOIDCTokenSourceRereadsTokenFile(t *testing.T) {
+	// The access token expires within tokenRefreshMargin, so every call
+	// refreshes it
+	endpoint := &testTokenEndpoint{expiresIn: 1}
+	server := endpoint.serve(t)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	source := &oidcTokenSource{
+		httpClient: server.Client(),
+		tokenURL:   server.URL + tokenExchangePath,
+		tokenFile:  tokenFile,
+	}
+
+	for _, federatedToken := range []string{"jwt-1", "jwt-2"} {
+		if err := os.WriteFile(tokenFile, []byte(federatedToken+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := source.Token(context.Background()); err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+	}
+
+	got := endpoint.subjectTokens()
+	if len(got) != 2 || got[0] != "jwt-1" || got[1] != "jwt-2" {
+		t.Errorf("exchanged subject tokens = %v, want [jwt-1 jwt-2]", got)
+	}
+}
+
+func TestOIDCTokenSourceRequestURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantMethod string
+		response   string
+	}{
+		{
+			name:       "GitHub Actions",
+			path:       "/_apis/token?audience=datahub",
+			wantMethod: http.MethodGet,
+			response:   `{"count": 1, "value": "github-jwt"}`,
+		},
+		{
+			name:       "Azure DevOps",
+			path:       "/project/_apis/distributedtask/hubs/build/plans/plan/jobs/job/oidctoken?api-version=7.1-preview.1",
+			wantMethod: http.MethodPost,
+			response:   `{"oidcToken": "azure-jwt"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ci := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.wantMethod {
+					t.Errorf("OIDC token request method = %s, want %s", r.Method, tt.wantMethod)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer request-token" {
+					t.Errorf("OIDC token request Authorization = %q, want the request token", got)
+				}
+				fmt.Fprint(w, tt.response)
+			}))
+			t.Cleanup(ci.Close)
+
+			endpoint := &testTokenEndpoint{expiresIn: 3600}
+			server := endpoint.serve(t)
+
+			// The CI system and the Datahub API are reached with their own
+			// clients
+			apiTransport := &recordingTransport{}
+			issuerTransport := &recordingTransport{}
+			source := &oidcTokenSource{
+				httpClient:       &http.Client{Transport: apiTransport},
+				issuerHTTPClient: &http.Client{Transport: issuerTransport},
+				tokenURL:         server.URL + tokenExchangePath,
+				requestURL:       ci.URL + tt.path,
+				requestToken:     "request-token",
+			}
+			if _, err := source.Token(context.Background()); err != nil {
+				t.Fatalf("Token() error = %v", err)
+			}
+			if got := apiTransport.hosts(); len(got) != 1 || got[0] != server.Listener.Addr().String() {
+				t.Errorf("API client requests = %v, want only the token endpoint", got)
+			}
+			if got := issuerTransport.hosts(); len(got) != 1 || got[0] != ci.Listener.Addr().String() {
+				t.Errorf("issuer client requests = %v, want only the CI system", got)
+			}
+
+			wantToken := map[string]string{http.MethodGet: "github-jwt", http.MethodPost: "azure-jwt"}[tt.wantMethod]
+			if got := endpoint.subjectTokens(); len(got) != 1 || got[0] != wantToken {
+				t.Errorf("exchanged subject tokens = %v, want [%s]", got, wantToken)
+			}
+		})
+	}
+}
+
+func TestOIDCTokenSourceExchangeError(t *testing.T) {
+	endpoint := &testTokenEndpoint{status: http.StatusBadRequest}
+	server := endpoint.serve(t)
+
+	source := &oidcTokenSource{
+		httpClient: server.Client(),
+		tokenURL:   server.URL + tokenExchangePath,
+		token:      "federated-jwt",
+	}
+
+	_, err := source.Token(context.Background())
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Token() error = %v, want an *apiError", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "invalid_grant" || !isAuthenticationError(apiErr) {
+		t.Errorf("Token() error = %+v, want a 400 invalid_grant authentication error", apiErr)
+	}
+}
+
+func TestGrantedTokenFresh(t *testing.T) {
+	tests := []struct {
+		name  string
+		token grantedToken
+		want  bool
+	}{
+		{"empty", grantedToken{}, false},
+		{"valid", grantedToken{value: "token", expiresAt: time.Now().Add(time.Hour)}, true},
+		{"within refresh margin", grantedToken{value: "token", expiresAt: time.Now().Add(tokenRefreshMargin / 2)}, false},
+		{"expired", grantedToken{value: "token", expiresAt: time.Now().Add(-time.Minute)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.token.fresh(); got != tt.want {
+				t.Errorf("fresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingTransport records the hosts of the requests it sends.
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (c *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, req.URL.Host)
+	c.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (c *recordingTransport) hosts() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.requests...)
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"sort"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// topologicalOrder returns the steps of the pipeline ordered so that every
+// step comes after the steps it depends on. Steps that can run at the same
+// time are ordered by name, so the order is stable between plans. When the
+// dependencies contain a cycle, the order is nil and the cycle is returned
+// instead, starting and ending with the same step.
+func topologicalOrder(dependencies map[string][]string) (order []string, cycle []string) {
+	names := sortedKeys(dependencies)
+
+	remaining := map[string]int{}
+	dependents := map[string][]string{}
+	for _, name := range names {
+		remaining[name] = len(dependencies[name])
+		for _, dependency := range dependencies[name] {
+			dependents[dependency] = append(dependents[dependency], name)
+		}
+	}
+
+	ready := []string{}
+	for _, name := range names {
+		if remaining[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+
+		for _, dependent := range dependents[name] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+				sort.Strings(ready)
+			}
+		}
+	}
+
+	if len(order) == len(names) {
+		return order, nil
+	}
+
+	return nil, findCycle(dependencies, names)
+}
+
+// findCycle returns a cycle in the dependencies, starting and ending with the
+// same step, or nil when there is none.
+func findCycle(dependencies map[string][]string, names []string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	stack := []string{}
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		stack = append(stack, name)
+
+		for _, dependency := range dependencies[name] {
+			switch state[dependency] {
+			case visiting:
+				start := slices.Index(stack, dependency)
+				return append(slices.Clone(stack[start:]), dependency)
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range names {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,601 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// PipelineOnFailureStop skips the remaining steps once a step failed.
+	PipelineOnFailureStop = "stop"
+	// PipelineOnFailureContinue keeps running the steps that don't depend on
+	// the failed step.
+	PipelineOnFailureContinue = "continue"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                   = &pipelineResource{}
+	_ resource.ResourceWithConfigure      = &pipelineResource{}
+	_ resource.ResourceWithImportState    = &pipelineResource{}
+	_ resource.ResourceWithValidateConfig = &pipelineResource{}
+	_ resource.ResourceWithModifyPlan     = &pipelineResource{}
+)
+
+// NewPipelineResource is a helper function to simplify the provider implementation.
+func NewPipelineResource() resource.Resource {
+	return &pipelineResource{}
+}
+
+// pipelineResource is the resource implementation.
+type pipelineResource struct {
+	client *datahub.DatahubClient
+}
+
+// Metadata returns the resource type name.
+func (r *pipelineResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_pipeline"
+}
+
+// Schema defines the schema for the resource.
+func (r *pipelineResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a pipeline that runs several Datahub jobs in the order of their dependencies.",
+		Attributes: map[string]schema.Attribute{
+			"pipeline_id": schema.StringAttribute{
+				Description: "Identifier of the pipeline.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Description: "Name of the pipeline.",
+				Required:    true,
+			},
+			"schedule": schema.StringAttribute{
+				Description: "Cron expression the pipeline is started on, like 0 6 * * MON-FRI. Without it the pipeline only runs when started manually.",
+				Optional:    true,
+				Validators: []validator.String{
+					cronValidator(),
+				},
+			},
+			"on_failure": schema.StringAttribute{
+				Description: "What happens when a step fails: stop (default) skips all remaining steps, continue still runs the steps that don't depend on the failed step.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(PipelineOnFailureStop, PipelineOnFailureContinue),
+				},
+			},
+			"steps": schema.MapNestedAttribute{
+				Description: "Steps of the pipeline by name. The steps must not depend on each other in a cycle.",
+				Required:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"job_id": schema.StringAttribute{
+							Description: "job_id of the datahub_job the step runs",
+							Required:    true,
+							Validators: []validator.String{
+								uuidValidator(),
+							},
+						},
+						"depends_on": schema.SetAttribute{
+							Description: "names of the steps that must have finished before this step starts",
+							ElementType: types.StringType,
+							Optional:    true,
+						},
+					},
+				},
+			},
+			"execution_order": schema.ListAttribute{
+				Description: "Names of the steps in the order they run in. Steps without a dependency between them are ordered by name. Null when a step depends on a missing step or the steps depend on each other in a cycle.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// ValidateConfig checks that every step only depends on existing steps, that
+// no job is used twice and that the steps don't depend on each other in a cycle.
+func (r *pipelineResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var steps types.Map
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("steps"), &steps)...)
+	if resp.Diagnostics.HasError() || steps.IsNull() || steps.IsUnknown() {
+		return
+	}
+
+	stepModels := map[string]pipelineStepModel{}
+	resp.Diagnostics.Append(steps.ElementsAs(ctx, &stepModels, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	stepByJobID := map[string]string{}
+	for _, name := range sortedKeys(stepModels) {
+		jobID := stepModels[name].JobID
+		if jobID.IsNull() || jobID.IsUnknown() {
+			continue
+		}
+
+		if other, ok := stepByJobID[jobID.ValueString()]; ok {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("steps").AtMapKey(name).AtName("job_id"),
+				"Duplicate Pipeline Job",
+				"The steps "+other+" and "+name+" both run job "+jobID.ValueString()+", a job can only be part of a pipeline once.",
+			)
+			continue
+		}
+		stepByJobID[jobID.ValueString()] = name
+	}
+
+	dependencies, known, diags := pipelineDependencies(ctx, stepModels)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || !known {
+		return
+	}
+
+	for _, name := range sortedKeys(dependencies) {
+		for _, dependency := range dependencies[name] {
+			if _, ok := dependencies[dependency]; !ok {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("steps").AtMapKey(name).AtName("depends_on"),
+					"Unknown Pipeline Step",
+					"The step "+name+" depends on "+dependency+", which is not a step of the pipeline.",
+				)
+			}
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if _, cycle := topologicalOrder(dependencies); cycle != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("steps"),
+			"Pipeline Dependency Cycle",
+			"The steps of the pipeline depend on each other in a cycle: "+strings.Join(cycle, " -> ")+". "+
+				"Remove one of these dependencies so the steps can run in order.",
+		)
+	}
+}
+
+// ModifyPlan shows the order the steps will run in.
+func (r *pipelineResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do when the resource is being destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var steps types.Map
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("steps"), &steps)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	executionOrder := types.ListUnknown(types.StringType)
+	if !steps.IsNull() && !steps.IsUnknown() {
+		stepModels := map[string]pipelineStepModel{}
+		resp.Diagnostics.Append(steps.ElementsAs(ctx, &stepModels, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		var diags diag.Diagnostics
+		executionOrder, diags = pipelineExecutionOrder(ctx, stepModels)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("execution_order"), executionOrder)...)
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *pipelineResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_pipeline", "Create")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from plan
+	var plan pipelineResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pipelineRequest, diags := plan.toAPI(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pipeline, err := r.client.Pipeline.Create(ctx, pipelineRequest)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error creating pipeline",
+			"Could not create pipeline",
+			err, path.Root("name"), req.Plan.Schema,
+		)...)
+		return
+	}
+
+	plan.PipelineID = types.StringValue(pipeline.ID.String())
+
+	plan.ExecutionOrder, diags = appliedPipelineExecutionOrder(ctx, plan.Steps)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *pipelineResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_pipeline", "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Get current state
+	var state pipelineResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pipelineID, err := uuid.Parse(state.PipelineID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading Datahub Pipeline",
+			"Could not parse pipeline ID "+state.PipelineID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	pipeline, err := r.client.Pipeline.Get(ctx, pipelineID)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Reading Datahub Pipeline",
+			"Could not read Datahub pipeline ID "+state.PipelineID.ValueString(),
+			err, path.Empty(), req.State.Schema,
+		)...)
+		return
+	}
+
+	state.Name = types.StringValue(pipeline.Name)
+
+	if pipeline.Schedule != "" {
+		state.Schedule = types.StringValue(pipeline.Schedule)
+	} else {
+		state.Schedule = types.StringNull()
+	}
+
+	// An unset on_failure equals the default, so only track it when configured
+	if !state.OnFailure.IsNull() || pipeline.OnFailure != PipelineOnFailureStop {
+		state.OnFailure = types.StringValue(pipeline.OnFailure)
+	}
+
+	// Steps are identified by job, so imported pipelines name their steps
+	// after the job ID
+	nameByJobID := map[uuid.UUID]string{}
+	for name, step := range state.Steps {
+		if jobID, err := uuid.Parse(step.JobID.ValueString()); err == nil {
+			nameByJobID[jobID] = name
+		}
+	}
+	stepName := func(jobID uuid.UUID) string {
+		if name, ok := nameByJobID[jobID]; ok {
+			return name
+		}
+		return jobID.String()
+	}
+
+	steps := map[string]pipelineStepModel{}
+	for _, step := range pipeline.Steps {
+		name := stepName(step.JobID)
+
+		dependsOn := types.SetNull(types.StringType)
+		if len(step.DependsOn) > 0 || !state.Steps[name].DependsOn.IsNull() {
+			dependencies := []string{}
+			for _, dependency := range step.DependsOn {
+				dependencies = append(dependencies, stepName(dependency))
+			}
+
+			dependsOn, diags = types.SetValueFrom(ctx, types.StringType, dependencies)
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+
+		steps[name] = pipelineStepModel{
+			JobID:     types.StringValue(step.JobID.String()),
+			DependsOn: dependsOn,
+		}
+	}
+	state.Steps = steps
+
+	state.ExecutionOrder, diags = pipelineExecutionOrder(ctx, steps)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *pipelineResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_pipeline", "Update")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from plan
+	var plan pipelineResourceModel
+	var state pipelineResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pipelineID, err := uuid.Parse(state.PipelineID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Updating Datahub Pipeline",
+			"Could not parse pipeline ID "+state.PipelineID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	pipelineRequest, diags := plan.toAPI(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, err = r.client.Pipeline.Update(ctx, pipelineID, pipelineRequest)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Updating Datahub Pipeline",
+			"Could not update Datahub pipeline ID "+state.PipelineID.ValueString(),
+			err, path.Root("name"), req.Plan.Schema,
+		)...)
+		return
+	}
+
+	plan.ExecutionOrder, diags = appliedPipelineExecutionOrder(ctx, plan.Steps)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *pipelineResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_pipeline", "Delete")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from state
+	var state pipelineResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pipelineID, err := uuid.Parse(state.PipelineID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Deleting Datahub Pipeline",
+			"Could not parse pipeline ID "+state.PipelineID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	err = r.client.Pipeline.Delete(ctx, pipelineID)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Deleting Datahub Pipeline",
+			"Could not delete pipeline",
+			err, path.Empty(), req.State.Schema,
+		)...)
+		return
+	}
+}
+
+func (r *pipelineResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.client = req.ProviderData.(*datahubProviderData).client
+}
+
+func (r *pipelineResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_pipeline", "ImportState")
+	defer endSpan(span, &resp.Diagnostics)
+
+	resource.ImportStatePassthroughID(ctx, path.Root("pipeline_id"), req, resp)
+}
+
+type pipelineResourceModel struct {
+	PipelineID     types.String                 `tfsdk:"pipeline_id"`
+	Name           types.String                 `tfsdk:"name"`
+	Schedule       types.String                 `tfsdk:"schedule"`
+	OnFailure      types.String                 `tfsdk:"on_failure"`
+	Steps          map[string]pipelineStepModel `tfsdk:"steps"`
+	ExecutionOrder types.List                   `tfsdk:"execution_order"`
+}
+
+type pipelineStepModel struct {
+	JobID     types.String `tfsdk:"job_id"`
+	DependsOn types.Set    `tfsdk:"depends_on"`
+}
+
+// toAPI converts the pipeline to its API representation, with the steps in
+// execution order and the dependencies referring to job IDs.
+func (m pipelineResourceModel) toAPI(ctx context.Context) (datahub.PipelineRequest, diag.Diagnostics) {
+	pipelineRequest := datahub.PipelineRequest{
+		Name:      m.Name.ValueString(),
+		Schedule:  m.Schedule.ValueString(),
+		OnFailure: m.OnFailure.ValueString(),
+		Steps:     []datahub.PipelineStep{},
+	}
+	if m.OnFailure.IsNull() {
+		pipelineRequest.OnFailure = PipelineOnFailureStop
+	}
+
+	dependencies, _, diags := pipelineDependencies(ctx, m.Steps)
+	if diags.HasError() {
+		return pipelineRequest, diags
+	}
+
+	jobIDs := map[string]uuid.UUID{}
+	for name, step := range m.Steps {
+		jobID, err := uuid.Parse(step.JobID.ValueString())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("steps").AtMapKey(name).AtName("job_id"),
+				"Invalid Job ID",
+				"Could not parse job ID "+step.JobID.ValueString()+": "+err.Error(),
+			)
+			return pipelineRequest, diags
+		}
+		jobIDs[name] = jobID
+	}
+
+	order, cycle := topologicalOrder(dependencies)
+	if cycle != nil {
+		diags.AddAttributeError(
+			path.Root("steps"),
+			"Pipeline Dependency Cycle",
+			"The steps of the pipeline depend on each other in a cycle: "+strings.Join(cycle, " -> ")+".",
+		)
+		return pipelineRequest, diags
+	}
+
+	for _, name := range order {
+		step := datahub.PipelineStep{JobID: jobIDs[name], DependsOn: []uuid.UUID{}}
+		for _, dependency := range dependencies[name] {
+			step.DependsOn = append(step.DependsOn, jobIDs[dependency])
+		}
+		pipelineRequest.Steps = append(pipelineRequest.Steps, step)
+	}
+
+	return pipelineRequest, diags
+}
+
+// pipelineDependencies returns the names of the steps each step depends on.
+// known is false when any depends_on is still unknown.
+func pipelineDependencies(ctx context.Context, steps map[string]pipelineStepModel) (dependencies map[string][]string, known bool, diags diag.Diagnostics) {
+	dependencies = map[string][]string{}
+	known = true
+
+	for name, step := range steps {
+		if step.DependsOn.IsUnknown() {
+			known = false
+			continue
+		}
+
+		for _, element := range step.DependsOn.Elements() {
+			if element.IsUnknown() {
+				known = false
+			}
+		}
+		if !known {
+			continue
+		}
+
+		stepDependencies := []string{}
+		diags.Append(step.DependsOn.ElementsAs(ctx, &stepDependencies, false)...)
+		dependencies[name] = stepDependencies
+	}
+
+	return dependencies, known, diags
+}
+
+// pipelineExecutionOrder returns the execution_order of the steps, unknown
+// while the dependencies aren't known yet. Steps that depend on a missing
+// step or on each other in a cycle have no order, ValidateConfig reports them
+// for configured pipelines, so the order is null with only a warning logged.
+func pipelineExecutionOrder(ctx context.Context, steps map[string]pipelineStepModel) (types.List, diag.Diagnostics) {
+	dependencies, known, diags := pipelineDependencies(ctx, steps)
+	if diags.HasError() || !known {
+		return types.ListUnknown(types.StringType), diags
+	}
+
+	for _, name := range sortedKeys(dependencies) {
+		for _, dependency := range dependencies[name] {
+			if _, ok := dependencies[dependency]; !ok {
+				tflog.Warn(ctx, "Pipeline step depends on a missing step, leaving execution_order null", map[string]any{
+					"step":       name,
+					"depends_on": dependency,
+				})
+				return types.ListNull(types.StringType), diags
+			}
+		}
+	}
+
+	order, cycle := topologicalOrder(dependencies)
+	if cycle != nil {
+		tflog.Warn(ctx, "Pipeline steps depend on each other in a cycle, leaving execution_order null", map[string]any{
+			"cycle": strings.Join(cycle, " -> "),
+		})
+		return types.ListNull(types.StringType), diags
+	}
+
+	return types.ListValueFrom(ctx, types.StringType, order)
+}
+
+// appliedPipelineExecutionOrder returns the execution_order of the steps of
+// an applied plan. The steps are all known by then, so the order planned as
+// unknown is known now, and never stored as unknown.
+func appliedPipelineExecutionOrder(ctx context.Context, steps map[string]pipelineStepModel) (types.List, diag.Diagnostics) {
+	executionOrder, diags := pipelineExecutionOrder(ctx, steps)
+	if executionOrder.IsUnknown() {
+		return types.ListNull(types.StringType), diags
+	}
+	return executionOrder, diags
+}
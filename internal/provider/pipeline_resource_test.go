@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testPipelineSteps returns steps with the given dependencies, nil for a step
+// without depends_on.
+func testPipelineSteps(dependencies map[string][]string) map[string]pipelineStepModel {
+	steps := map[string]pipelineStepModel{}
+	for name, stepDependencies := range dependencies {
+		dependsOn := types.SetNull(types.StringType)
+		if stepDependencies != nil {
+			elements := []attr.Value{}
+			for _, dependency := range stepDependencies {
+				elements = append(elements, types.StringValue(dependency))
+			}
+			dependsOn = types.SetValueMust(types.StringType, elements)
+		}
+		steps[name] = pipelineStepModel{JobID: types.StringValue(name), DependsOn: dependsOn}
+	}
+	return steps
+}
+
+func TestPipelineExecutionOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		steps       map[string]pipelineStepModel
+		want        []string
+		wantNull    bool
+		wantUnknown bool
+	}{
+		{
+			name:  "ordered by dependencies, then by name",
+			steps: testPipelineSteps(map[string][]string{"load": {"extract"}, "extract": nil, "audit": nil, "report": {"load", "audit"}}),
+			want:  []string{"audit", "extract", "load", "report"},
+		},
+		{
+			name:     "dependency on a missing step",
+			steps:    testPipelineSteps(map[string][]string{"load": {"extract"}}),
+			wantNull: true,
+		},
+		{
+			name:     "cycle",
+			steps:    testPipelineSteps(map[string][]string{"a": {"b"}, "b": {"a"}}),
+			wantNull: true,
+		},
+		{
+			name: "unknown dependencies",
+			steps: map[string]pipelineStepModel{
+				"load": {JobID: types.StringValue("load"), DependsOn: types.SetUnknown(types.StringType)},
+			},
+			wantUnknown: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := pipelineExecutionOrder(context.Background(), tt.steps)
+			if diags.HasError() {
+				t.Fatalf("pipelineExecutionOrder() diagnostics = %v", diags)
+			}
+
+			switch {
+			case tt.wantNull:
+				if !got.IsNull() {
+					t.Errorf("pipelineExecutionOrder() = %v, want null", got)
+				}
+			case tt.wantUnknown:
+				if !got.IsUnknown() {
+					t.Errorf("pipelineExecutionOrder() = %v, want unknown", got)
+				}
+			default:
+				var order []string
+				got.ElementsAs(context.Background(), &order, false)
+				if !reflect.DeepEqual(order, tt.want) {
+					t.Errorf("pipelineExecutionOrder() = %v, want %v", order, tt.want)
+				}
+			}
+
+			applied, _ := appliedPipelineExecutionOrder(context.Background(), tt.steps)
+			if applied.IsUnknown() {
+				t.Errorf("appliedPipelineExecutionOrder() = %v, want it known", applied)
+			}
+		})
+	}
+}
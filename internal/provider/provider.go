@@ -2,18 +2,18 @@ package provider
 
 import (
 	"context"
-	"crypto/tls"
-
-	"os"
+	"strings"
 
 	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
@@ -27,19 +27,70 @@ type datahubProviderModel struct {
 	BaseURL      types.String `tfsdk:"base_url"`
 	ClientID     types.String `tfsdk:"client_id"`
 	ClientSecret types.String `tfsdk:"client_secret"`
+
+	Profile            types.String `tfsdk:"profile"`
+	CredentialsCommand types.String `tfsdk:"credentials_command"`
+
+	OIDCToken        types.String `tfsdk:"oidc_token"`
+	OIDCTokenFile    types.String `tfsdk:"oidc_token_file"`
+	OIDCRequestURL   types.String `tfsdk:"oidc_request_url"`
+	OIDCRequestToken types.String `tfsdk:"oidc_request_token"`
+
+	ClientExpiryWarningDays types.Int64 `tfsdk:"client_expiry_warning_days"`
+
+	ProxyURL           types.String `tfsdk:"proxy_url"`
+	RequestTimeout     types.String `tfsdk:"request_timeout"`
+	MaxIdleConnections types.Int64  `tfsdk:"max_idle_connections"`
+	UserAgentSuffix    types.String `tfsdk:"user_agent_suffix"`
+
+	RequestsPerSecond     types.Int64 `tfsdk:"requests_per_second"`
+	MaxConcurrentRequests types.Int64 `tfsdk:"max_concurrent_requests"`
+
+	DebugHTTP types.Bool `tfsdk:"debug_http"`
+
+	VerifyOnConfigure types.Bool `tfsdk:"verify_on_configure"`
+}
+
+// defaultClientExpiryWarningDays is used when client_expiry_warning_days is not configured.
+const defaultClientExpiryWarningDays = 30
+
+// datahubProviderData is made available to resources and data sources by Configure.
+type datahubProviderData struct {
+	client *datahub.DatahubClient
+
+	// clientExpiryWarningDays is the number of days before expiry from which
+	// datahub_client warns about its expiration date, 0 disables the warning.
+	clientExpiryWarningDays int64
+
+	// imageDigestResolver resolves image tags for jobs with pin_digest.
+	imageDigestResolver *imageDigestResolver
+
+	// tenants hands out the clients of resources with act_as_client_id.
+	tenants *tenantClients
+
+	// callerIdentity looks up the identity of the provider credentials.
+	callerIdentity *callerIdentityResolver
 }
 
 // New is a helper function to simplify provider server and testing implementation.
-func New() provider.Provider {
-	return &datahubProvider{}
+func New(version string) func() provider.Provider {
+	return func() provider.Provider {
+		return &datahubProvider{
+			version: version,
+		}
+	}
 }
 
 // DatahubProvider is the provider implementation.
-type datahubProvider struct{}
+type datahubProvider struct {
+	// version is the release of the provider, "dev" for local builds.
+	version string
+}
 
 // Metadata returns the provider type name.
 func (p *datahubProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "datahub"
+	resp.Version = p.version
 }
 
 // Schema defines the provider-level schema for configuration data.
@@ -48,8 +99,12 @@ func (p *datahubProvider) Schema(_ context.Context, _ provider.SchemaRequest, re
 		Description: "Interact with Datahub.",
 		Attributes: map[string]schema.Attribute{
 			"base_url": schema.StringAttribute{
-				Description: "Base URL for the datahub api, like: https://api.datahub.allyourbi.nl",
-				Optional:    true,
+				Description: "Base URL for the datahub api, like: https://api.datahub.allyourbi.nl. " +
+					"May also be provided via DATAHUB_BASE_URL or, for compatibility, DATAHUB_HOST environment variable.",
+				Optional: true,
+				Validators: []validator.String{
+					httpURLValidator{},
+				},
 			},
 			"client_id": schema.StringAttribute{
 				Description: "Client ID for the Datahub Engine API. May also be provided via DATAHUB_CLIENT_ID environment variable.",
@@ -60,12 +115,103 @@ func (p *datahubProvider) Schema(_ context.Context, _ provider.SchemaRequest, re
 				Optional:    true,
 				Sensitive:   true,
 			},
+			"profile": schema.StringAttribute{
+				Description: "Profile in the credentials file ~/.datahub/credentials to read base_url, client_id, client_secret and credentials_command from. " +
+					"May also be provided via DATAHUB_PROFILE environment variable, defaults to the default profile. The file location can be changed with DATAHUB_CREDENTIALS_FILE.",
+				Optional: true,
+			},
+			"credentials_command": schema.StringAttribute{
+				Description: "Command that writes the credentials as a JSON object with client_id, client_secret and optionally base_url to stdout, run through the shell when client_id or client_secret isn't set otherwise. " +
+					"May also be provided via DATAHUB_CREDENTIALS_COMMAND environment variable.",
+				Optional: true,
+			},
+			"oidc_token": schema.StringAttribute{
+				Description: "OIDC ID token, like the one of a CI workload identity, exchanged for a Datahub access token instead of authenticating with client_secret. " +
+					"May also be provided via DATAHUB_OIDC_TOKEN environment variable.",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"oidc_token_file": schema.StringAttribute{
+				Description: "File to read the OIDC ID token from, read again whenever the access token is refreshed. " +
+					"May also be provided via DATAHUB_OIDC_TOKEN_FILE environment variable.",
+				Optional: true,
+			},
+			"oidc_request_url": schema.StringAttribute{
+				Description: "URL to request the OIDC ID token from, like ACTIONS_ID_TOKEN_REQUEST_URL in GitHub Actions or SYSTEM_OIDCREQUESTURI in Azure DevOps. " +
+					"May also be provided via DATAHUB_OIDC_REQUEST_URL environment variable.",
+				Optional: true,
+			},
+			"oidc_request_token": schema.StringAttribute{
+				Description: "Bearer token for oidc_request_url. May also be provided via DATAHUB_OIDC_REQUEST_TOKEN, " +
+					"ACTIONS_ID_TOKEN_REQUEST_TOKEN or SYSTEM_ACCESSTOKEN environment variables.",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"client_expiry_warning_days": schema.Int64Attribute{
+				Description: "Number of days before a datahub_client expires from which a warning is shown during plan and refresh. Defaults to 30, set to 0 to disable.",
+				Optional:    true,
+			},
+			"proxy_url": schema.StringAttribute{
+				Description: "URL of the HTTP proxy to send requests through, like http://proxy.example.com:3128. " +
+					"Defaults to the HTTPS_PROXY and HTTP_PROXY environment variables, hosts in NO_PROXY are always reached directly.",
+				Optional: true,
+				Validators: []validator.String{
+					httpURLValidator{},
+				},
+			},
+			"request_timeout": schema.StringAttribute{
+				Description: "Time limit for a single HTTP request, like 60s or 5m. Defaults to 30s.",
+				Optional:    true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"max_idle_connections": schema.Int64Attribute{
+				Description: "Maximum number of idle connections kept open per host. Defaults to 100.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 10000),
+				},
+			},
+			"user_agent_suffix": schema.StringAttribute{
+				Description: "Text appended to the User-Agent header of every request, like the name of the build agent, to tell traffic apart in the server logs.",
+				Optional:    true,
+			},
+			"requests_per_second": schema.Int64Attribute{
+				Description: "Maximum number of requests per second to the Datahub API, shared by all resources and data sources of the provider. Unlimited by default.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 10000),
+				},
+			},
+			"debug_http": schema.BoolAttribute{
+				Description: "Log the method, URL, status, duration, headers and bodies of every HTTP request at debug level, with credentials and secrets redacted. " +
+					"May also be enabled via DATAHUB_DEBUG_HTTP environment variable, and is always enabled with TF_LOG_PROVIDER=TRACE.",
+				Optional: true,
+			},
+			"verify_on_configure": schema.BoolAttribute{
+				Description: "Fetch an access token and look up the identity of the credentials while configuring the provider, so a wrong base_url or credentials are reported before any resource is touched. " +
+					"Defaults to true, may also be set via DATAHUB_VERIFY_ON_CONFIGURE environment variable.",
+				Optional: true,
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				Description: "Maximum number of requests to the Datahub API running at the same time, shared by all resources and data sources of the provider. Unlimited by default.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 1000),
+				},
+			},
 		},
 	}
 }
 
 // Configure prepares a Datahub API client for data sources and resources.
+// The configuration is resolved by providerConfigResolver, see there for the
+// precedence of its sources.
 func (p *datahubProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	ctx, span := startSpan(ctx, "datahub", "Configure")
+	defer endSpan(span, &resp.Diagnostics)
+
 	tflog.Info(ctx, "Configuring Datahub client")
 
 	// Retrieve provider data from configuration
@@ -76,105 +222,65 @@ func (p *datahubProvider) Configure(ctx context.Context, req provider.ConfigureR
 		return
 	}
 
-	// If practitioner provided a configuration value for any of the
-	// attributes, it must be a known value.
-
-	if config.BaseURL.IsUnknown() {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("BaseURL"),
-			"Unknown Datahub API BaseURL",
-			"The provider cannot create the Datahub API client as there is an unknown configuration value for the Datahub API host. "+
-				"Either target apply the source of the value first, set the value statically in the configuration, or use the DATAHUB_HOST environment variable.",
-		)
-	}
-
-	if config.ClientID.IsUnknown() {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("client_id"),
-			"Unknown Datahub API ClientID",
-			"The provider cannot create the Datahub API client as there is an unknown configuration value for the Datahub API username. "+
-				"Either target apply the source of the value first, set the value statically in the configuration, or use the DATAHUB_USERNAME environment variable.",
-		)
-	}
-
-	if config.ClientSecret.IsUnknown() {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("client_secret"),
-			"Unknown Datahub API ClientSecret",
-			"The provider cannot create the Datahub API client as there is an unknown configuration value for the Datahub API password. "+
-				"Either target apply the source of the value first, set the value statically in the configuration, or use the DATAHUB_PASSWORD environment variable.",
-		)
-	}
-
+	resolved, diags := newProviderConfigResolver().resolve(ctx, config)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Default values to environment variables, but override
-	// with Terraform configuration value if set.
-
-	base_url := os.Getenv("DATAHUB_HOST")
-	client_id := os.Getenv("DATAHUB_CLIENT_ID")
-	client_secret := os.Getenv("DATAHUB_CLIENT_SECRET")
-
-	if !config.BaseURL.IsNull() {
-		base_url = config.BaseURL.ValueString()
-	}
-
-	if !config.ClientID.IsNull() {
-		client_id = config.ClientID.ValueString()
-	}
-
-	if !config.ClientSecret.IsNull() {
-		client_secret = config.ClientSecret.ValueString()
-	}
-
-	// If any of the expected configurations are missing, return
-	// errors with provider-specific guidance.
-
-	if base_url == "" {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("host"),
-			"Missing Datahub API Host",
-			"The provider cannot create the Datahub API client as there is a missing or empty value for the Datahub API host. "+
-				"Set the host value in the configuration or use the DATAHUB_HOST environment variable. "+
-				"If either is already set, ensure the value is not empty.",
-		)
-	}
-
-	if client_id == "" {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("username"),
-			"Missing Datahub API Username",
-			"The provider cannot create the Datahub API client as there is a missing or empty value for the Datahub API username. "+
-				"Set the username value in the configuration or use the DATAHUB_USERNAME environment variable. "+
-				"If either is already set, ensure the value is not empty.",
-		)
-	}
-
-	if client_secret == "" {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("password"),
-			"Missing Datahub API Password",
-			"The provider cannot create the Datahub API client as there is a missing or empty value for the Datahub API password. "+
-				"Set the password value in the configuration or use the DATAHUB_PASSWORD environment variable. "+
-				"If either is already set, ensure the value is not empty.",
-		)
-	}
-
-	if resp.Diagnostics.HasError() {
-		return
+	// Never log the credentials themselves, and keep them out of any log
+	// line that might echo them
+	for _, secret := range []string{resolved.clientSecret, resolved.oidcToken, resolved.oidcRequestToken} {
+		if secret != "" {
+			ctx = tflog.MaskAllFieldValuesStrings(ctx, secret)
+			ctx = tflog.MaskMessageStrings(ctx, secret)
+		}
 	}
-
-	ctx = tflog.SetField(ctx, "DATAHUB_BASE_URL", base_url)
-	ctx = tflog.SetField(ctx, "DATAHUB_CLIENT_ID", client_id)
-	ctx = tflog.SetField(ctx, "DATAHUB_CLIENT_SECRET", client_secret)
-	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, "DATAHUB_CLIENT_SECRET")
+	ctx = tflog.SetField(ctx, "datahub_base_url", resolved.baseURL)
+	ctx = tflog.SetField(ctx, "datahub_client_id", resolved.clientID)
 
 	tflog.Debug(ctx, "Creating Datahub client")
 
-	// Create a new Datahub client using the configuration values
-	client, err := datahub.FromCredentials(client_id, client_secret)
+	httpConfig := resolved.http
+	httpConfig.userAgent = userAgent(p.version, req.TerraformVersion, resolved.userAgentSuffix)
+
+	// Endpoints outside the Datahub API, like the OIDC token request of the
+	// CI system and image registries, are reached with the default TLS
+	// settings and don't count towards the limits of the Datahub API
+	otherHTTPConfig := httpConfig
+	otherHTTPConfig.limiter = nil
+	httpClient := newHTTPClient(otherHTTPConfig, nil)
+
+	httpConfig.recordAPIErrors = true
+	apiHTTPClient := newHTTPClient(httpConfig, apiTLSConfig())
+
+	// Create a new Datahub client using the configuration values. The SDK
+	// and the caller identity share the token source, so they use the same
+	// access token. The token endpoint is part of the Datahub API, so every
+	// token is requested over the same transport as the requests of the SDK.
+	tokenURL := strings.TrimSuffix(resolved.baseURL, "/") + tokenExchangePath
+	var tokenSource grantSource
+	if resolved.useOIDC() {
+		tflog.Debug(ctx, "Authenticating with OIDC token exchange")
+		tokenSource = &oidcTokenSource{
+			httpClient:       apiHTTPClient,
+			issuerHTTPClient: httpClient,
+			tokenURL:         tokenURL,
+			clientID:         resolved.clientID,
+			token:            resolved.oidcToken,
+			tokenFile:        resolved.oidcTokenFile,
+			requestURL:       resolved.oidcRequestURL,
+			requestToken:     resolved.oidcRequestToken,
+		}
+	} else {
+		tokenSource = &clientCredentialsTokenSource{
+			httpClient:   apiHTTPClient,
+			tokenURL:     tokenURL,
+			clientID:     resolved.clientID,
+			clientSecret: resolved.clientSecret,
+		}
+	}
+	client, err := datahub.FromTokenSource(tokenSource)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to Create Datahub API Client",
@@ -184,31 +290,69 @@ func (p *datahubProvider) Configure(ctx context.Context, req provider.ConfigureR
 		)
 		return
 	}
-	client, err = client.WithBaseURL(base_url)
-	client = client.WithTLSConfig(&tls.Config{InsecureSkipVerify: true})
+	client, err = client.WithBaseURL(resolved.baseURL)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.AddAttributeError(
+			path.Root("base_url"),
 			"Unable to Create Datahub API Client",
-			"An unexpected error occurred when creating the Datahub API client. "+
+			"An unexpected error occurred when setting the Datahub API base URL. "+
 				"If the error is not clear, please contact the provider developers.\n\n"+
 				"Datahub Client Error: "+err.Error(),
 		)
 		return
 	}
-	// if err != nil {
-	// 	resp.Diagnostics.AddError(
-	// 		"Unable to Create Datahub API Client",
-	// 		"An unexpected error occurred when creating the Datahub API client. "+
-	// 			"If the error is not clear, please contact the provider developers.\n\n"+
-	// 			"Datahub Client Error: "+err.Error(),
-	// 	)
-	// 	return
-	// }
+	client = client.WithHTTPClient(apiHTTPClient)
+
+	// Resources with act_as_client_id get a client acting as that client,
+	// exchanging the access token of the provider at the same token endpoint
+	newTenantClient := func(actAsClientID string) (*datahub.DatahubClient, error) {
+		tenantTokenSource := &impersonationTokenSource{
+			httpClient:    apiHTTPClient,
+			tokenURL:      tokenURL,
+			actAsClientID: actAsClientID,
+			subject:       tokenSource,
+		}
+		tenantClient, err := datahub.FromTokenSource(tenantTokenSource)
+		if err != nil {
+			return nil, err
+		}
+		tenantClient, err = tenantClient.WithBaseURL(resolved.baseURL)
+		if err != nil {
+			return nil, err
+		}
+		return tenantClient.WithHTTPClient(apiHTTPClient), nil
+	}
+
+	providerData := &datahubProviderData{
+		client:                  client,
+		clientExpiryWarningDays: resolved.clientExpiryWarningDays,
+		imageDigestResolver:     newImageDigestResolver(httpClient),
+		tenants:                 &tenantClients{client: client, newTenantClient: newTenantClient},
+		callerIdentity: &callerIdentityResolver{
+			httpClient:  apiHTTPClient,
+			identityURL: strings.TrimSuffix(resolved.baseURL, "/") + callerIdentityPath,
+			tokenSource: tokenSource,
+		},
+	}
+
+	if resolved.verifyOnConfigure {
+		tflog.Debug(ctx, "Verifying Datahub credentials")
+
+		identity, err := providerData.callerIdentity.get(ctx)
+		if err != nil {
+			resp.Diagnostics.Append(callerIdentityDiagnostics(err, verifyOnConfigureHint)...)
+			return
+		}
+		tflog.Debug(ctx, "Verified Datahub credentials", map[string]any{
+			"caller_client_id":     identity.ClientID,
+			"caller_customer_code": identity.CustomerCode,
+		})
+	}
 
 	// Make the Datahub client available during DataSource and Resource
 	// type Configure methods.
-	resp.DataSourceData = client
-	resp.ResourceData = client
+	resp.DataSourceData = providerData
+	resp.ResourceData = providerData
 
 	tflog.Info(ctx, "Configured Datahub client", map[string]any{"success": true})
 }
@@ -217,6 +361,9 @@ func (p *datahubProvider) Configure(ctx context.Context, req provider.ConfigureR
 func (p *datahubProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewOAuthURLDataSource,
+		NewClientDataSource,
+		NewClientsDataSource,
+		NewCallerIdentityDataSource,
 	}
 }
 
@@ -226,5 +373,10 @@ func (p *datahubProvider) Resources(_ context.Context) []func() resource.Resourc
 		NewJobResource,
 		NewInitRunResource,
 		NewClientResource,
+		NewRegistryCredentialResource,
+		NewNotificationChannelResource,
+		NewPipelineResource,
+		NewJobEnvironmentVariableResource,
+		NewJobSecretResource,
 	}
 }
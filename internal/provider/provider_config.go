@@ -0,0 +1,369 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// providerSetting is a provider attribute that may also be set through
+// environment variables, the first variable that is set is used.
+type providerSetting struct {
+	attribute string
+	envVars   []string
+}
+
+var (
+	baseURLSetting            = providerSetting{"base_url", []string{"DATAHUB_BASE_URL", "DATAHUB_HOST"}}
+	clientIDSetting           = providerSetting{"client_id", []string{"DATAHUB_CLIENT_ID"}}
+	clientSecretSetting       = providerSetting{"client_secret", []string{"DATAHUB_CLIENT_SECRET"}}
+	profileSetting            = providerSetting{"profile", []string{"DATAHUB_PROFILE"}}
+	credentialsCommandSetting = providerSetting{"credentials_command", []string{"DATAHUB_CREDENTIALS_COMMAND"}}
+	oidcTokenSetting          = providerSetting{"oidc_token", []string{"DATAHUB_OIDC_TOKEN"}}
+	oidcTokenFileSetting      = providerSetting{"oidc_token_file", []string{"DATAHUB_OIDC_TOKEN_FILE"}}
+	oidcRequestURLSetting     = providerSetting{"oidc_request_url", []string{"DATAHUB_OIDC_REQUEST_URL"}}
+	oidcRequestTokenSetting   = providerSetting{"oidc_request_token", []string{"DATAHUB_OIDC_REQUEST_TOKEN", "ACTIONS_ID_TOKEN_REQUEST_TOKEN", "SYSTEM_ACCESSTOKEN"}}
+)
+
+// envHint names the environment variables of the setting for diagnostics.
+func (s providerSetting) envHint() string {
+	if len(s.envVars) == 1 {
+		return "the " + s.envVars[0] + " environment variable"
+	}
+	return "the " + strings.Join(s.envVars[:len(s.envVars)-1], ", ") + " or " + s.envVars[len(s.envVars)-1] + " environment variables"
+}
+
+// providerConfig is the provider configuration after resolving all sources.
+type providerConfig struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+
+	oidcToken        string
+	oidcTokenFile    string
+	oidcRequestURL   string
+	oidcRequestToken string
+
+	clientExpiryWarningDays int64
+
+	// verifyOnConfigure looks up the caller identity during Configure, so
+	// a wrong base_url or credentials fail early.
+	verifyOnConfigure bool
+
+	// http configures the HTTP clients, except for the User-Agent which
+	// userAgentSuffix is appended to.
+	http            httpClientConfig
+	userAgentSuffix string
+}
+
+// useOIDC reports whether the client authenticates by exchanging a federated
+// OIDC token, which replaces the client secret unless one is set.
+func (c *providerConfig) useOIDC() bool {
+	return c.clientSecret == "" && (c.oidcToken != "" || c.oidcTokenFile != "" || c.oidcRequestURL != "")
+}
+
+// providerConfigResolver resolves the provider configuration. Every value is
+// taken from the first source that sets it:
+//
+//  1. the provider configuration
+//  2. environment variables
+//  3. the output of credentials_command
+//  4. the profile in the credentials file
+//
+// The environment, credentials file and command are injectable so resolution
+// doesn't depend on the machine it runs on.
+type providerConfigResolver struct {
+	getenv                func(string) string
+	credentialsFile       string
+	runCredentialsCommand func(ctx context.Context, command string) (*credentials, error)
+}
+
+// newProviderConfigResolver returns a resolver reading the process environment.
+func newProviderConfigResolver() *providerConfigResolver {
+	return &providerConfigResolver{
+		getenv:                os.Getenv,
+		credentialsFile:       defaultCredentialsFilePath(os.Getenv),
+		runCredentialsCommand: runCredentialsCommand,
+	}
+}
+
+// lookup returns the configured value of the setting, or the value of its
+// environment variables when it isn't configured.
+func (r *providerConfigResolver) lookup(setting providerSetting, value types.String) string {
+	if !value.IsNull() {
+		return value.ValueString()
+	}
+	for _, name := range setting.envVars {
+		if value := r.getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// resolve resolves the provider configuration, returning errors on the
+// attribute paths of the values that are unknown, missing or invalid.
+func (r *providerConfigResolver) resolve(ctx context.Context, config datahubProviderModel) (*providerConfig, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	// If practitioner provided a configuration value for any of the
+	// attributes, it must be a known value.
+	for _, attribute := range []struct {
+		setting providerSetting
+		value   types.String
+	}{
+		{baseURLSetting, config.BaseURL},
+		{clientIDSetting, config.ClientID},
+		{clientSecretSetting, config.ClientSecret},
+		{profileSetting, config.Profile},
+		{credentialsCommandSetting, config.CredentialsCommand},
+		{oidcTokenSetting, config.OIDCToken},
+		{oidcTokenFileSetting, config.OIDCTokenFile},
+		{oidcRequestURLSetting, config.OIDCRequestURL},
+		{oidcRequestTokenSetting, config.OIDCRequestToken},
+	} {
+		if attribute.value.IsUnknown() {
+			diags.AddAttributeError(
+				path.Root(attribute.setting.attribute),
+				"Unknown Datahub Provider Configuration",
+				"The provider cannot create the Datahub API client as there is an unknown configuration value for "+attribute.setting.attribute+". "+
+					"Either target apply the source of the value first, set the value statically in the configuration, or use "+attribute.setting.envHint()+".",
+			)
+		}
+	}
+
+	for _, attribute := range []struct {
+		name  string
+		value attr.Value
+	}{
+		{"client_expiry_warning_days", config.ClientExpiryWarningDays},
+		{"proxy_url", config.ProxyURL},
+		{"request_timeout", config.RequestTimeout},
+		{"max_idle_connections", config.MaxIdleConnections},
+		{"user_agent_suffix", config.UserAgentSuffix},
+		{"requests_per_second", config.RequestsPerSecond},
+		{"max_concurrent_requests", config.MaxConcurrentRequests},
+		{"debug_http", config.DebugHTTP},
+		{"verify_on_configure", config.VerifyOnConfigure},
+	} {
+		if attribute.value.IsUnknown() {
+			diags.AddAttributeError(
+				path.Root(attribute.name),
+				"Unknown Datahub Provider Configuration",
+				"The provider cannot create the Datahub API client as there is an unknown configuration value for "+attribute.name+". "+
+					"Either target apply the source of the value first, or set the value statically in the configuration.",
+			)
+		}
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	resolved := &providerConfig{
+		baseURL:          r.lookup(baseURLSetting, config.BaseURL),
+		clientID:         r.lookup(clientIDSetting, config.ClientID),
+		clientSecret:     r.lookup(clientSecretSetting, config.ClientSecret),
+		oidcToken:        r.lookup(oidcTokenSetting, config.OIDCToken),
+		oidcTokenFile:    r.lookup(oidcTokenFileSetting, config.OIDCTokenFile),
+		oidcRequestURL:   r.lookup(oidcRequestURLSetting, config.OIDCRequestURL),
+		oidcRequestToken: r.lookup(oidcRequestTokenSetting, config.OIDCRequestToken),
+
+		clientExpiryWarningDays: defaultClientExpiryWarningDays,
+		verifyOnConfigure:       true,
+	}
+
+	if !config.ClientExpiryWarningDays.IsNull() {
+		resolved.clientExpiryWarningDays = config.ClientExpiryWarningDays.ValueInt64()
+	}
+
+	if resolved.clientExpiryWarningDays < 0 {
+		diags.AddAttributeError(
+			path.Root("client_expiry_warning_days"),
+			"Invalid Client Expiry Warning Days",
+			"The client_expiry_warning_days value must be zero or greater.",
+		)
+		return nil, diags
+	}
+
+	if !config.VerifyOnConfigure.IsNull() {
+		resolved.verifyOnConfigure = config.VerifyOnConfigure.ValueBool()
+	} else if verify := r.getenv("DATAHUB_VERIFY_ON_CONFIGURE"); verify != "" {
+		enabled, err := strconv.ParseBool(verify)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("verify_on_configure"),
+				"Invalid DATAHUB_VERIFY_ON_CONFIGURE Environment Variable",
+				"The DATAHUB_VERIFY_ON_CONFIGURE environment variable must be true or false, got "+strconv.Quote(verify)+".",
+			)
+			return nil, diags
+		}
+		resolved.verifyOnConfigure = enabled
+	}
+
+	httpConfig, httpDiags := r.resolveHTTP(config)
+	diags.Append(httpDiags...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	resolved.http = httpConfig
+	resolved.userAgentSuffix = config.UserAgentSuffix.ValueString()
+
+	// Fill in what is still missing from the credentials command and the
+	// profile in the credentials file, so switching tenants only takes a
+	// different profile
+	profile := r.lookup(profileSetting, config.Profile)
+	explicitProfile := profile != ""
+	if !explicitProfile {
+		profile = defaultProfile
+	}
+
+	profileCredentials, err := loadCredentialsProfile(r.credentialsFile, profile, explicitProfile)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("profile"),
+			"Unable to Load Datahub Profile",
+			"The provider cannot read the credentials of profile "+profile+": "+err.Error(),
+		)
+		return nil, diags
+	}
+
+	credentialsCommand := firstNonEmpty(r.lookup(credentialsCommandSetting, config.CredentialsCommand), profileCredentials.CredentialsCommand)
+
+	commandCredentials := &credentials{}
+	if credentialsCommand != "" && !resolved.useOIDC() && (resolved.clientID == "" || resolved.clientSecret == "") {
+		tflog.Debug(ctx, "Running Datahub credentials command")
+
+		commandCredentials, err = r.runCredentialsCommand(ctx, credentialsCommand)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("credentials_command"),
+				"Unable to Run Datahub Credentials Command",
+				"The provider cannot get credentials from the credentials command: "+err.Error(),
+			)
+			return nil, diags
+		}
+	}
+
+	resolved.baseURL = firstNonEmpty(resolved.baseURL, commandCredentials.BaseURL, profileCredentials.BaseURL)
+	resolved.clientID = firstNonEmpty(resolved.clientID, commandCredentials.ClientID, profileCredentials.ClientID)
+	resolved.clientSecret = firstNonEmpty(resolved.clientSecret, commandCredentials.ClientSecret, profileCredentials.ClientSecret)
+
+	// If any of the expected configurations are missing, return
+	// errors with provider-specific guidance.
+
+	if resolved.baseURL == "" {
+		diags.AddAttributeError(
+			path.Root("base_url"),
+			"Missing Datahub API Base URL",
+			"The provider cannot create the Datahub API client as there is a missing or empty value for the Datahub API base URL. "+
+				"Set base_url in the configuration, use "+baseURLSetting.envHint()+", or set base_url in the credentials file profile. "+
+				"If either is already set, ensure the value is not empty.",
+		)
+	} else if err := checkHTTPURL(resolved.baseURL); err != nil {
+		diags.AddAttributeError(
+			path.Root("base_url"),
+			"Invalid Datahub API Base URL",
+			"The Datahub API base URL must be an absolute http or https URL, like https://api.datahub.allyourbi.nl, got "+
+				`"`+resolved.baseURL+`": `+err.Error(),
+		)
+	}
+
+	if resolved.clientID == "" && !resolved.useOIDC() {
+		diags.AddAttributeError(
+			path.Root("client_id"),
+			"Missing Datahub API Client ID",
+			"The provider cannot create the Datahub API client as there is a missing or empty value for the Datahub API client ID. "+
+				"Set client_id in the configuration, use "+clientIDSetting.envHint()+", or set client_id in the credentials file profile. "+
+				"If either is already set, ensure the value is not empty.",
+		)
+	}
+
+	if resolved.clientSecret == "" && !resolved.useOIDC() {
+		diags.AddAttributeError(
+			path.Root("client_secret"),
+			"Missing Datahub API Client Secret",
+			"The provider cannot create the Datahub API client as there is a missing or empty value for the Datahub API client secret. "+
+				"Set client_secret in the configuration, use "+clientSecretSetting.envHint()+", or set client_secret in the credentials file profile. "+
+				"To authenticate without a client secret, configure oidc_token, oidc_token_file or oidc_request_url instead. "+
+				"If either is already set, ensure the value is not empty.",
+		)
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	resolved.http.secrets = []string{resolved.clientSecret, resolved.oidcToken, resolved.oidcRequestToken}
+
+	return resolved, diags
+}
+
+// resolveHTTP resolves the HTTP client configuration. Without proxy_url the
+// proxy is taken from HTTPS_PROXY and HTTP_PROXY, and NO_PROXY is respected
+// either way.
+func (r *providerConfigResolver) resolveHTTP(config datahubProviderModel) (httpClientConfig, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	resolved := httpClientConfig{
+		proxy: &httpproxy.Config{
+			HTTPProxy:  firstNonEmpty(r.getenv("HTTP_PROXY"), r.getenv("http_proxy")),
+			HTTPSProxy: firstNonEmpty(r.getenv("HTTPS_PROXY"), r.getenv("https_proxy")),
+			NoProxy:    firstNonEmpty(r.getenv("NO_PROXY"), r.getenv("no_proxy")),
+		},
+		requestTimeout:     defaultRequestTimeout,
+		maxIdleConnections: defaultMaxIdleConnections,
+	}
+
+	if proxyURL := config.ProxyURL.ValueString(); proxyURL != "" {
+		resolved.proxy.HTTPProxy = proxyURL
+		resolved.proxy.HTTPSProxy = proxyURL
+	}
+
+	if !config.RequestTimeout.IsNull() {
+		timeout, err := parseDuration(config.RequestTimeout.ValueString())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("request_timeout"),
+				"Invalid Request Timeout",
+				"The request_timeout value must be a duration like 60s or 5m: "+err.Error(),
+			)
+			return resolved, diags
+		}
+		resolved.requestTimeout = timeout
+	}
+
+	if !config.MaxIdleConnections.IsNull() {
+		resolved.maxIdleConnections = int(config.MaxIdleConnections.ValueInt64())
+	}
+
+	resolved.limiter = newRequestLimiter(int(config.RequestsPerSecond.ValueInt64()), int(config.MaxConcurrentRequests.ValueInt64()))
+
+	// Tracing is also enabled by provider trace logging, which is as verbose
+	// as it gets anyway
+	resolved.trace = strings.EqualFold(r.getenv("TF_LOG_PROVIDER"), "TRACE")
+	if !config.DebugHTTP.IsNull() {
+		resolved.trace = resolved.trace || config.DebugHTTP.ValueBool()
+	} else if debugHTTP := r.getenv("DATAHUB_DEBUG_HTTP"); debugHTTP != "" {
+		enabled, err := strconv.ParseBool(debugHTTP)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("debug_http"),
+				"Invalid DATAHUB_DEBUG_HTTP Environment Variable",
+				"The DATAHUB_DEBUG_HTTP environment variable must be true or false, got "+strconv.Quote(debugHTTP)+".",
+			)
+			return resolved, diags
+		}
+		resolved.trace = resolved.trace || enabled
+	}
+
+	return resolved, diags
+}
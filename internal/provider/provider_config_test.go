@@ -0,0 +1,422 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testResolver returns a resolver with the environment, credentials file
+// and credentials command of the test instead of the machine's.
+func testResolver(t *testing.T, env map[string]string, credentialsFile string, command *credentials) *providerConfigResolver {
+	t.Helper()
+
+	resolver := &providerConfigResolver{
+		getenv: func(name string) string { return env[name] },
+		runCredentialsCommand: func(context.Context, string) (*credentials, error) {
+			if command == nil {
+				t.Error("credentials command run, but none is expected to")
+				return &credentials{}, nil
+			}
+			return command, nil
+		},
+	}
+
+	if credentialsFile != "" {
+		resolver.credentialsFile = filepath.Join(t.TempDir(), "credentials")
+		if err := os.WriteFile(resolver.credentialsFile, []byte(credentialsFile), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resolver
+}
+
+func TestProviderConfigResolverPrecedence(t *testing.T) {
+	const profile = `
+[default]
+base_url            = https://profile.example.com
+client_id           = profile-id
+client_secret       = profile-secret
+credentials_command = fetch-credentials
+`
+
+	tests := []struct {
+		name            string
+		config          datahubProviderModel
+		env             map[string]string
+		credentialsFile string
+		command         *credentials
+		want            providerConfig
+	}{
+		{
+			name: "configuration",
+			config: datahubProviderModel{
+				BaseURL:      types.StringValue("https://config.example.com"),
+				ClientID:     types.StringValue("config-id"),
+				ClientSecret: types.StringValue("config-secret"),
+			},
+			env: map[string]string{
+				"DATAHUB_BASE_URL":      "https://env.example.com",
+				"DATAHUB_CLIENT_ID":     "env-id",
+				"DATAHUB_CLIENT_SECRET": "env-secret",
+			},
+			credentialsFile: profile,
+			want:            providerConfig{baseURL: "https://config.example.com", clientID: "config-id", clientSecret: "config-secret"},
+		},
+		{
+			name: "environment over credentials command",
+			env: map[string]string{
+				"DATAHUB_BASE_URL":  "https://env.example.com",
+				"DATAHUB_CLIENT_ID": "env-id",
+			},
+			credentialsFile: profile,
+			command:         &credentials{BaseURL: "https://command.example.com", ClientID: "command-id", ClientSecret: "command-secret"},
+			want:            providerConfig{baseURL: "https://env.example.com", clientID: "env-id", clientSecret: "command-secret"},
+		},
+		{
+			name:            "credentials command over profile",
+			credentialsFile: profile,
+			command:         &credentials{ClientSecret: "command-secret"},
+			want:            providerConfig{baseURL: "https://profile.example.com", clientID: "profile-id", clientSecret: "command-secret"},
+		},
+		{
+			name:            "profile",
+			credentialsFile: "[default]\nbase_url = https://profile.example.com\nclient_id = profile-id\nclient_secret = profile-secret\n",
+			want:            providerConfig{baseURL: "https://profile.example.com", clientID: "profile-id", clientSecret: "profile-secret"},
+		},
+		{
+			name: "selected profile",
+			env:  map[string]string{"DATAHUB_PROFILE": "tenant-b"},
+			credentialsFile: "[default]\nbase_url = https://a.example.com\nclient_id = a\nclient_secret = a\n" +
+				"[tenant-b]\nbase_url = https://b.example.com\nclient_id = b\nclient_secret = b\n",
+			want: providerConfig{baseURL: "https://b.example.com", clientID: "b", clientSecret: "b"},
+		},
+		{
+			name: "DATAHUB_HOST fallback",
+			env: map[string]string{
+				"DATAHUB_HOST":          "https://host.example.com",
+				"DATAHUB_CLIENT_ID":     "env-id",
+				"DATAHUB_CLIENT_SECRET": "env-secret",
+			},
+			want: providerConfig{baseURL: "https://host.example.com", clientID: "env-id", clientSecret: "env-secret"},
+		},
+		{
+			name: "DATAHUB_BASE_URL over DATAHUB_HOST",
+			env: map[string]string{
+				"DATAHUB_BASE_URL":      "https://base.example.com",
+				"DATAHUB_HOST":          "https://host.example.com",
+				"DATAHUB_CLIENT_ID":     "env-id",
+				"DATAHUB_CLIENT_SECRET": "env-secret",
+			},
+			want: providerConfig{baseURL: "https://base.example.com", clientID: "env-id", clientSecret: "env-secret"},
+		},
+		{
+			name: "OIDC without client secret",
+			env: map[string]string{
+				"DATAHUB_BASE_URL":         "https://env.example.com",
+				"DATAHUB_OIDC_REQUEST_URL": "https://ci.example.com/token",
+				"SYSTEM_ACCESSTOKEN":       "request-token",
+			},
+			credentialsFile: profile,
+			want: providerConfig{
+				baseURL:          "https://env.example.com",
+				clientID:         "profile-id",
+				clientSecret:     "profile-secret",
+				oidcRequestURL:   "https://ci.example.com/token",
+				oidcRequestToken: "request-token",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, diags := testResolver(t, tt.env, tt.credentialsFile, tt.command).resolve(context.Background(), tt.config)
+			if diags.HasError() {
+				t.Fatalf("resolve() diagnostics = %v", diags)
+			}
+
+			got := providerConfig{
+				baseURL:          resolved.baseURL,
+				clientID:         resolved.clientID,
+				clientSecret:     resolved.clientSecret,
+				oidcRequestURL:   resolved.oidcRequestURL,
+				oidcRequestToken: resolved.oidcRequestToken,
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderConfigResolverDefaults(t *testing.T) {
+	env := map[string]string{
+		"DATAHUB_BASE_URL":      "https://env.example.com",
+		"DATAHUB_CLIENT_ID":     "env-id",
+		"DATAHUB_CLIENT_SECRET": "env-secret",
+	}
+
+	resolved, diags := testResolver(t, env, "", nil).resolve(context.Background(), datahubProviderModel{})
+	if diags.HasError() {
+		t.Fatalf("resolve() diagnostics = %v", diags)
+	}
+
+	if resolved.clientExpiryWarningDays != defaultClientExpiryWarningDays {
+		t.Errorf("clientExpiryWarningDays = %d, want %d", resolved.clientExpiryWarningDays, defaultClientExpiryWarningDays)
+	}
+	if !resolved.verifyOnConfigure {
+		t.Error("verifyOnConfigure = false, want it on by default")
+	}
+	if resolved.http.requestTimeout != defaultRequestTimeout || resolved.http.maxIdleConnections != defaultMaxIdleConnections {
+		t.Errorf("http = %+v, want the default timeout and idle connections", resolved.http)
+	}
+	if resolved.http.trace {
+		t.Error("http.trace = true, want false")
+	}
+}
+
+func TestProviderConfigResolverVerifyOnConfigure(t *testing.T) {
+	tests := []struct {
+		name   string
+		config types.Bool
+		env    string
+		want   bool
+	}{
+		{"default", types.BoolNull(), "", true},
+		{"environment", types.BoolNull(), "false", false},
+		{"configuration", types.BoolValue(false), "", false},
+		{"configuration over environment", types.BoolValue(true), "false", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{
+				"DATAHUB_BASE_URL":            "https://env.example.com",
+				"DATAHUB_CLIENT_ID":           "env-id",
+				"DATAHUB_CLIENT_SECRET":       "env-secret",
+				"DATAHUB_VERIFY_ON_CONFIGURE": tt.env,
+			}
+
+			resolved, diags := testResolver(t, env, "", nil).resolve(context.Background(), datahubProviderModel{VerifyOnConfigure: tt.config})
+			if diags.HasError() {
+				t.Fatalf("resolve() diagnostics = %v", diags)
+			}
+			if resolved.verifyOnConfigure != tt.want {
+				t.Errorf("verifyOnConfigure = %v, want %v", resolved.verifyOnConfigure, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderConfigResolverDiagnostics(t *testing.T) {
+	credentialsEnv := map[string]string{
+		"DATAHUB_CLIENT_ID":     "env-id",
+		"DATAHUB_CLIENT_SECRET": "env-secret",
+	}
+
+	tests := []struct {
+		name            string
+		config          datahubProviderModel
+		env             map[string]string
+		credentialsFile string
+		command         *credentials
+		commandErr      error
+		wantPaths       []path.Path
+	}{
+		{
+			name:      "missing everything",
+			wantPaths: []path.Path{path.Root("base_url"), path.Root("client_id"), path.Root("client_secret")},
+		},
+		{
+			name:      "missing client secret",
+			env:       map[string]string{"DATAHUB_BASE_URL": "https://env.example.com", "DATAHUB_CLIENT_ID": "env-id"},
+			wantPaths: []path.Path{path.Root("client_secret")},
+		},
+		{
+			name:      "empty configuration value",
+			config:    datahubProviderModel{ClientID: types.StringValue("")},
+			env:       map[string]string{"DATAHUB_BASE_URL": "https://env.example.com", "DATAHUB_CLIENT_ID": "env-id", "DATAHUB_CLIENT_SECRET": "env-secret"},
+			wantPaths: []path.Path{path.Root("client_id")},
+		},
+		{
+			name:      "invalid base_url",
+			config:    datahubProviderModel{BaseURL: types.StringValue("api.datahub.example.com")},
+			env:       credentialsEnv,
+			wantPaths: []path.Path{path.Root("base_url")},
+		},
+		{
+			name:      "base_url with unsupported scheme",
+			config:    datahubProviderModel{BaseURL: types.StringValue("ftp://api.datahub.example.com")},
+			env:       credentialsEnv,
+			wantPaths: []path.Path{path.Root("base_url")},
+		},
+		{
+			name: "unknown values",
+			config: datahubProviderModel{
+				ClientSecret:   types.StringUnknown(),
+				RequestTimeout: types.StringUnknown(),
+			},
+			wantPaths: []path.Path{path.Root("client_secret"), path.Root("request_timeout")},
+		},
+		{
+			name:      "missing explicit profile",
+			env:       map[string]string{"DATAHUB_PROFILE": "tenant-b"},
+			wantPaths: []path.Path{path.Root("profile")},
+		},
+		{
+			name:            "profile not in credentials file",
+			config:          datahubProviderModel{Profile: types.StringValue("tenant-b")},
+			credentialsFile: "[default]\nclient_id = a\n",
+			wantPaths:       []path.Path{path.Root("profile")},
+		},
+		{
+			name:       "failing credentials command",
+			config:     datahubProviderModel{CredentialsCommand: types.StringValue("false")},
+			commandErr: errors.New("exit status 1"),
+			wantPaths:  []path.Path{path.Root("credentials_command")},
+		},
+		{
+			name:      "negative client_expiry_warning_days",
+			config:    datahubProviderModel{ClientExpiryWarningDays: types.Int64Value(-1)},
+			wantPaths: []path.Path{path.Root("client_expiry_warning_days")},
+		},
+		{
+			name:      "invalid DATAHUB_DEBUG_HTTP",
+			env:       map[string]string{"DATAHUB_DEBUG_HTTP": "sometimes"},
+			wantPaths: []path.Path{path.Root("debug_http")},
+		},
+		{
+			name:      "invalid DATAHUB_VERIFY_ON_CONFIGURE",
+			env:       map[string]string{"DATAHUB_VERIFY_ON_CONFIGURE": "sometimes"},
+			wantPaths: []path.Path{path.Root("verify_on_configure")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := testResolver(t, tt.env, tt.credentialsFile, tt.command)
+			if tt.commandErr != nil {
+				resolver.runCredentialsCommand = func(context.Context, string) (*credentials, error) {
+					return nil, tt.commandErr
+				}
+			}
+			if tt.credentialsFile == "" {
+				// A file that doesn't exist, like on a machine without one
+				resolver.credentialsFile = filepath.Join(t.TempDir(), "credentials")
+			}
+
+			resolved, diags := resolver.resolve(context.Background(), tt.config)
+			if resolved != nil {
+				t.Errorf("resolve() = %+v, want nil", resolved)
+			}
+
+			var gotPaths []path.Path
+			for _, d := range diags.Errors() {
+				withPath, ok := d.(interface{ Path() path.Path })
+				if !ok {
+					t.Errorf("diagnostic %q has no attribute path", d.Summary())
+					continue
+				}
+				gotPaths = append(gotPaths, withPath.Path())
+			}
+			if !reflect.DeepEqual(gotPaths, tt.wantPaths) {
+				t.Errorf("diagnostic paths = %v, want %v", gotPaths, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestParseCredentialsFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]*credentials
+		wantErr bool
+	}{
+		{
+			name: "INI",
+			data: `
+# Datahub credentials
+[default]
+base_url      = https://api.example.com
+client_id     = default-id
+client_secret = default-secret
+
+; other tools write profile sections like this
+[profile tenant-b]
+client_id           = b-id
+credentials_command = vault read -field=secret datahub/b
+`,
+			want: map[string]*credentials{
+				"default":  {BaseURL: "https://api.example.com", ClientID: "default-id", ClientSecret: "default-secret"},
+				"tenant-b": {ClientID: "b-id", CredentialsCommand: "vault read -field=secret datahub/b"},
+			},
+		},
+		{
+			name: "INI value containing =",
+			data: "[default]\nclient_secret = abc==\n",
+			want: map[string]*credentials{
+				"default": {ClientSecret: "abc=="},
+			},
+		},
+		{
+			name: "JSON",
+			data: `{
+  "default": {"base_url": "https://api.example.com", "client_id": "default-id", "client_secret": "default-secret"},
+  "tenant-b": {"client_id": "b-id", "credentials_command": "fetch b"}
+}`,
+			want: map[string]*credentials{
+				"default":  {BaseURL: "https://api.example.com", ClientID: "default-id", ClientSecret: "default-secret"},
+				"tenant-b": {ClientID: "b-id", CredentialsCommand: "fetch b"},
+			},
+		},
+		{
+			name: "empty",
+			data: "",
+			want: map[string]*credentials{},
+		},
+		{
+			name:    "INI key outside a profile",
+			data:    "client_id = a\n",
+			wantErr: true,
+		},
+		{
+			name:    "INI unknown key",
+			data:    "[default]\nclient_key = a\n",
+			wantErr: true,
+		},
+		{
+			name:    "INI line without value",
+			data:    "[default]\nclient_id\n",
+			wantErr: true,
+		},
+		{
+			name:    "INI empty profile name",
+			data:    "[ ]\nclient_id = a\n",
+			wantErr: true,
+		},
+		{
+			name:    "JSON unknown field",
+			data:    `{"default": {"client_key": "a"}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCredentialsFile([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCredentialsFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCredentialsFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package provider
+
+import (
+	"context"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &registryCredentialResource{}
+	_ resource.ResourceWithConfigure   = &registryCredentialResource{}
+	_ resource.ResourceWithImportState = &registryCredentialResource{}
+)
+
+// NewRegistryCredentialResource is a helper function to simplify the provider implementation.
+func NewRegistryCredentialResource() resource.Resource {
+	return &registryCredentialResource{}
+}
+
+// registryCredentialResource is the resource implementation.
+type registryCredentialResource struct {
+	client *datahub.DatahubClient
+}
+
+// Metadata returns the resource type name.
+func (r *registryCredentialResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_registry_credential"
+}
+
+// Schema defines the schema for the resource.
+func (r *registryCredentialResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages credentials Datahub uses to pull job images from a private container registry.",
+		Attributes: map[string]schema.Attribute{
+			"credential_id": schema.StringAttribute{
+				Description: "Identifier of the registry credential, referenced by image_pull_credential_id on jobs and init runs.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"server": schema.StringAttribute{
+				Description: "Registry server the credential is used for, like myregistry.azurecr.io",
+				Required:    true,
+			},
+			"username": schema.StringAttribute{
+				Description: "Username to log in to the registry with.",
+				Required:    true,
+			},
+			"password": schema.StringAttribute{
+				Description: "Password or access token to log in to the registry with. The API never returns it, so changes made outside Terraform are not detected.",
+				Required:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *registryCredentialResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_registry_credential", "Create")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from plan
+	var plan registryCredentialResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	credential, err := r.client.RegistryCredential.Create(ctx, datahub.RegistryCredentialRequest{
+		Server:   plan.Server.ValueString(),
+		Username: plan.Username.ValueString(),
+		Password: plan.Password.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error creating registry credential",
+			"Could not create registry credential",
+			err, path.Root("server"), req.Plan.Schema,
+		)...)
+		return
+	}
+
+	plan.CredentialID = types.StringValue(credential.ID.String())
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *registryCredentialResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_registry_credential", "Read")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Get current state
+	var state registryCredentialResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	credentialID, err := uuid.Parse(state.CredentialID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading Datahub Registry Credential",
+			"Could not parse credential ID "+state.CredentialID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	credential, err := r.client.RegistryCredential.Get(ctx, credentialID)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Reading Datahub Registry Credential",
+			"Could not read Datahub registry credential ID "+state.CredentialID.ValueString(),
+			err, path.Empty(), req.State.Schema,
+		)...)
+		return
+	}
+
+	state.Server = types.StringValue(credential.Server)
+	state.Username = types.StringValue(credential.Username)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *registryCredentialResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_registry_credential", "Update")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from plan
+	var plan registryCredentialResourceModel
+	var state registryCredentialResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	credentialID, err := uuid.Parse(state.CredentialID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Updating Datahub Registry Credential",
+			"Could not parse credential ID "+state.CredentialID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	_, err = r.client.RegistryCredential.Update(ctx, credentialID, datahub.RegistryCredentialRequest{
+		Server:   plan.Server.ValueString(),
+		Username: plan.Username.ValueString(),
+		Password: plan.Password.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Updating Datahub Registry Credential",
+			"Could not update Datahub registry credential ID "+state.CredentialID.ValueString(),
+			err, path.Root("server"), req.Plan.Schema,
+		)...)
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *registryCredentialResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_registry_credential", "Delete")
+	defer endSpan(span, &resp.Diagnostics)
+
+	// Retrieve values from state
+	var state registryCredentialResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	credentialID, err := uuid.Parse(state.CredentialID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Deleting Datahub Registry Credential",
+			"Could not parse credential ID "+state.CredentialID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	err = r.client.RegistryCredential.Delete(ctx, credentialID)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostics(ctx,
+			"Error Deleting Datahub Registry Credential",
+			"Could not delete registry credential",
+			err, path.Empty(), req.State.Schema,
+		)...)
+		return
+	}
+}
+
+func (r *registryCredentialResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	r.client = req.ProviderData.(*datahubProviderData).client
+}
+
+func (r *registryCredentialResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = withAPIErrorRecorder(ctx)
+	ctx, span := startSpan(ctx, "datahub_registry_credential", "ImportState")
+	defer endSpan(span, &resp.Diagnostics)
+
+	resource.ImportStatePassthroughID(ctx, path.Root("credential_id"), req, resp)
+}
+
+type registryCredentialResourceModel struct {
+	CredentialID types.String `tfsdk:"credential_id"`
+	Server       types.String `tfsdk:"server"`
+	Username     types.String `tfsdk:"username"`
+	Password     types.String `tfsdk:"password"`
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/time/rate"
+)
+
+// requestLimiter bounds the rate and concurrency of requests to the Datahub
+// API. One limiter is shared by all resources and data sources of a provider,
+// so Terraform's parallelism can't exceed the budget.
+type requestLimiter struct {
+	// rate limits the requests per second, nil means unlimited.
+	rate *rate.Limiter
+
+	// slots holds a token per running request, nil means unlimited.
+	slots chan struct{}
+}
+
+// newRequestLimiter returns a limiter allowing requestsPerSecond requests per
+// second, in bursts of at most one second worth of requests, and
+// maxConcurrentRequests requests at a time. Zero disables a limit, nil is
+// returned when both are disabled.
+func newRequestLimiter(requestsPerSecond, maxConcurrentRequests int) *requestLimiter {
+	if requestsPerSecond <= 0 && maxConcurrentRequests <= 0 {
+		return nil
+	}
+
+	limiter := &requestLimiter{}
+	if requestsPerSecond > 0 {
+		limiter.rate = rate.NewLimiter(rate.Limit(requestsPerSecond), requestsPerSecond)
+	}
+	if maxConcurrentRequests > 0 {
+		limiter.slots = make(chan struct{}, maxConcurrentRequests)
+	}
+	return limiter
+}
+
+// acquire waits until the request may be sent and returns the function that
+// frees its slot again.
+func (l *requestLimiter) acquire(ctx context.Context, req *http.Request) (func(), error) {
+	if l.rate != nil {
+		reservation := l.rate.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			tflog.Debug(ctx, "Waiting for Datahub API rate limit", map[string]any{
+				"method": req.Method,
+				"url":    req.URL.Redacted(),
+				"wait":   delay.String(),
+			})
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				reservation.Cancel()
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	if l.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+	default:
+		start := time.Now()
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		tflog.Debug(ctx, "Waited for a free Datahub API request slot", map[string]any{
+			"method":                  req.Method,
+			"url":                     req.URL.Redacted(),
+			"wait":                    time.Since(start).String(),
+			"max_concurrent_requests": cap(l.slots),
+		})
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-l.slots }) }, nil
+}
+
+// limitedTransport sends requests once the limiter allows them.
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *requestLimiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.acquire(req.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	// The request keeps its slot until its response has been read
+	resp.Body = &onCloseBody{ReadCloser: resp.Body, onClose: release}
+	return resp, nil
+}
@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+// syncBuffer is a buffer that concurrent requests can log to.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// messages returns the messages logged so far.
+func (b *syncBuffer) messages(t *testing.T) []string {
+	t.Helper()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries, err := tflogtest.MultilineJSONDecode(bytes.NewReader(b.buf.Bytes()))
+	if err != nil {
+		t.Fatalf("decoding logs: %v", err)
+	}
+	var messages []string
+	for _, entry := range entries {
+		messages = append(messages, entry["@message"].(string))
+	}
+	return messages
+}
+
+func countMessage(messages []string, message string) int {
+	count := 0
+	for _, m := range messages {
+		if m == message {
+			count++
+		}
+	}
+	return count
+}
+
+func TestNewRequestLimiterDisabled(t *testing.T) {
+	if limiter := newRequestLimiter(0, 0); limiter != nil {
+		t.Errorf("newRequestLimiter(0, 0) = %+v, want nil", limiter)
+	}
+}
+
+func TestRequestLimiterSharedBudget(t *testing.T) {
+	// Requests of different resources go through their own client, but
+	// share the limiter of the provider, so together they never exceed it
+	const maxConcurrent = 2
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if current <= max || maxInFlight.CompareAndSwap(max, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	t.Cleanup(server.Close)
+
+	limiter := newRequestLimiter(0, maxConcurrent)
+	clients := []*http.Client{
+		{Transport: &limitedTransport{base: http.DefaultTransport, limiter: limiter}},
+		{Transport: &limitedTransport{base: http.DefaultTransport, limiter: limiter}},
+	}
+
+	logs := &syncBuffer{}
+	ctx := tflogtest.RootLogger(context.Background(), logs)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(client *http.Client) {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/jobs", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+				return
+			}
+			resp.Body.Close()
+		}(clients[i%len(clients)])
+	}
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got > maxConcurrent {
+		t.Errorf("%d requests in flight, want at most %d", got, maxConcurrent)
+	}
+	if got := countMessage(logs.messages(t), "Waited for a free Datahub API request slot"); got == 0 {
+		t.Error("no wait for a request slot logged, want the waits of the queued requests")
+	}
+	if got := len(limiter.slots); got != 0 {
+		t.Errorf("%d slots still taken, want all released", got)
+	}
+}
+
+func TestRequestLimiterRateLimitWait(t *testing.T) {
+	// One request per second: the second request has to wait, and gives up
+	// when its context ends first
+	limiter := newRequestLimiter(1, 0)
+	logs := &syncBuffer{}
+	ctx := tflogtest.RootLogger(context.Background(), logs)
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/jobs", nil)
+
+	release, err := limiter.acquire(ctx, req)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	release()
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx, req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire() error = %v, want the deadline of the context", err)
+	}
+	if got := countMessage(logs.messages(t), "Waiting for Datahub API rate limit"); got != 1 {
+		t.Errorf("rate limit waits logged %d times, want 1", got)
+	}
+
+	// The cancelled request doesn't use up the budget of the next second
+	if delay := limiter.rate.Reserve().Delay(); delay > time.Second {
+		t.Errorf("next request waits %v, want at most a second", delay)
+	}
+}
+
+func TestRequestLimiterCancelWhileWaitingForSlot(t *testing.T) {
+	limiter := newRequestLimiter(0, 1)
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/jobs", nil)
+
+	release, err := limiter.acquire(context.Background(), req)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := limiter.acquire(ctx, req)
+		done <- err
+	}()
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire() error = %v, want context.Canceled", err)
+	}
+
+	// Releasing twice frees the slot once
+	release()
+	release()
+	if got := len(limiter.slots); got != 0 {
+		t.Fatalf("%d slots taken after release, want 0", got)
+	}
+	release, err = limiter.acquire(context.Background(), req)
+	if err != nil {
+		t.Fatalf("acquire() after release error = %v", err)
+	}
+	release()
+}
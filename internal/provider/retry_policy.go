@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"context"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// FailurePolicyFail marks the run as failed once all attempts failed.
+	FailurePolicyFail = "fail"
+	// FailurePolicyAlert marks the run as failed and raises an alert.
+	FailurePolicyAlert = "alert"
+	// FailurePolicySuspend marks the run as failed and suspends the job until
+	// it is updated again.
+	FailurePolicySuspend = "suspend"
+)
+
+// retryPolicyAttributes returns the attributes of the retry_policy block.
+func retryPolicyAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"max_attempts": schema.Int64Attribute{
+			Description: "maximum number of attempts for a run, including the first one",
+			Required:    true,
+			Validators: []validator.Int64{
+				int64validator.Between(1, 100),
+			},
+		},
+		"initial_backoff": schema.StringAttribute{
+			Description: "time to wait before the first retry, doubled for every next retry, like 30s or 5m",
+			Optional:    true,
+			Validators: []validator.String{
+				durationValidator{},
+			},
+		},
+		"max_backoff": schema.StringAttribute{
+			Description: "upper bound for the time to wait between retries, like 1h",
+			Optional:    true,
+			Validators: []validator.String{
+				durationValidator{},
+			},
+		},
+		"retry_on_exit_codes": schema.ListAttribute{
+			Description: "only retry when the container exits with one of these codes, by default every non-zero exit code is retried",
+			ElementType: types.Int64Type,
+			Optional:    true,
+			Validators: []validator.List{
+				listvalidator.ValueInt64sAre(int64validator.Between(1, 255)),
+			},
+		},
+	}
+}
+
+type retryPolicyModel struct {
+	MaxAttempts      types.Int64  `tfsdk:"max_attempts"`
+	InitialBackoff   types.String `tfsdk:"initial_backoff"`
+	MaxBackoff       types.String `tfsdk:"max_backoff"`
+	RetryOnExitCodes types.List   `tfsdk:"retry_on_exit_codes"`
+}
+
+// Equal reports whether both models hold the same values.
+func (m *retryPolicyModel) Equal(other *retryPolicyModel) bool {
+	if m == nil || other == nil {
+		return m == other
+	}
+
+	return m.MaxAttempts.Equal(other.MaxAttempts) &&
+		m.InitialBackoff.Equal(other.InitialBackoff) &&
+		m.MaxBackoff.Equal(other.MaxBackoff) &&
+		m.RetryOnExitCodes.Equal(other.RetryOnExitCodes)
+}
+
+// toAPI converts the retry_policy block to its API representation. A nil
+// block results in a single attempt without retries.
+func (m *retryPolicyModel) toAPI(ctx context.Context) (*datahub.RetryPolicy, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if m == nil {
+		return &datahub.RetryPolicy{MaxAttempts: 1}, diags
+	}
+
+	policy := &datahub.RetryPolicy{
+		MaxAttempts: int(m.MaxAttempts.ValueInt64()),
+	}
+
+	if !m.InitialBackoff.IsNull() {
+		initialBackoff, err := parseDuration(m.InitialBackoff.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("retry_policy").AtName("initial_backoff"), "Invalid Duration", err.Error())
+			return nil, diags
+		}
+		policy.InitialBackoff = initialBackoff
+	}
+
+	if !m.MaxBackoff.IsNull() {
+		maxBackoff, err := parseDuration(m.MaxBackoff.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("retry_policy").AtName("max_backoff"), "Invalid Duration", err.Error())
+			return nil, diags
+		}
+		policy.MaxBackoff = maxBackoff
+	}
+
+	diags.Append(m.RetryOnExitCodes.ElementsAs(ctx, &policy.RetryOnExitCodes, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	return policy, diags
+}
+
+// retryPolicyFromAPI converts the retry policy returned by the API to the
+// retry_policy block, keeping the state representation of equal durations.
+func retryPolicyFromAPI(ctx context.Context, state *retryPolicyModel, policy *datahub.RetryPolicy) (*retryPolicyModel, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if policy == nil || policy.MaxAttempts <= 1 && state == nil {
+		return nil, diags
+	}
+
+	if state == nil {
+		state = &retryPolicyModel{}
+	}
+
+	retryPolicy := &retryPolicyModel{
+		MaxAttempts:      types.Int64Value(int64(policy.MaxAttempts)),
+		InitialBackoff:   types.StringNull(),
+		MaxBackoff:       types.StringNull(),
+		RetryOnExitCodes: types.ListNull(types.Int64Type),
+	}
+
+	if policy.InitialBackoff > 0 {
+		retryPolicy.InitialBackoff = types.StringValue(policy.InitialBackoff.String())
+		if current, err := parseDuration(state.InitialBackoff.ValueString()); err == nil && current == policy.InitialBackoff {
+			retryPolicy.InitialBackoff = state.InitialBackoff
+		}
+	}
+
+	if policy.MaxBackoff > 0 {
+		retryPolicy.MaxBackoff = types.StringValue(policy.MaxBackoff.String())
+		if current, err := parseDuration(state.MaxBackoff.ValueString()); err == nil && current == policy.MaxBackoff {
+			retryPolicy.MaxBackoff = state.MaxBackoff
+		}
+	}
+
+	if len(policy.RetryOnExitCodes) > 0 {
+		retryPolicy.RetryOnExitCodes, diags = types.ListValueFrom(ctx, types.Int64Type, policy.RetryOnExitCodes)
+	}
+
+	return retryPolicy, diags
+}
+
+// validateRetryPolicy checks that initial_backoff doesn't exceed max_backoff.
+func validateRetryPolicy(ctx context.Context, config tfsdk.Config, diags *diag.Diagnostics) {
+	var initialBackoff, maxBackoff types.String
+	diags.Append(config.GetAttribute(ctx, path.Root("retry_policy").AtName("initial_backoff"), &initialBackoff)...)
+	diags.Append(config.GetAttribute(ctx, path.Root("retry_policy").AtName("max_backoff"), &maxBackoff)...)
+	if diags.HasError() {
+		return
+	}
+
+	if initialBackoff.IsNull() || initialBackoff.IsUnknown() || maxBackoff.IsNull() || maxBackoff.IsUnknown() {
+		return
+	}
+
+	initial, errInitial := parseDuration(initialBackoff.ValueString())
+	max, errMax := parseDuration(maxBackoff.ValueString())
+	if errInitial == nil && errMax == nil && initial > max {
+		diags.AddAttributeError(
+			path.Root("retry_policy").AtName("initial_backoff"),
+			"Invalid Retry Backoff",
+			"The initial_backoff "+initialBackoff.ValueString()+" is larger than the max_backoff "+maxBackoff.ValueString()+".",
+		)
+	}
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestRetryPolicyToAPI(t *testing.T) {
+	ctx := context.Background()
+	exitCodes, _ := types.ListValueFrom(ctx, types.Int64Type, []int{1, 137})
+
+	tests := []struct {
+		name     string
+		model    *retryPolicyModel
+		want     *datahub.RetryPolicy
+		wantPath *path.Path
+	}{
+		{
+			name:  "no block",
+			model: nil,
+			want:  &datahub.RetryPolicy{MaxAttempts: 1},
+		},
+		{
+			name: "attempts only",
+			model: &retryPolicyModel{
+				MaxAttempts:      types.Int64Value(3),
+				InitialBackoff:   types.StringNull(),
+				MaxBackoff:       types.StringNull(),
+				RetryOnExitCodes: types.ListNull(types.Int64Type),
+			},
+			want: &datahub.RetryPolicy{MaxAttempts: 3},
+		},
+		{
+			name: "backoff and exit codes",
+			model: &retryPolicyModel{
+				MaxAttempts:      types.Int64Value(5),
+				InitialBackoff:   types.StringValue("30s"),
+				MaxBackoff:       types.StringValue("1d"),
+				RetryOnExitCodes: exitCodes,
+			},
+			want: &datahub.RetryPolicy{MaxAttempts: 5, InitialBackoff: 30 * time.Second, MaxBackoff: 24 * time.Hour, RetryOnExitCodes: []int{1, 137}},
+		},
+		{
+			name: "invalid max_backoff",
+			model: &retryPolicyModel{
+				MaxAttempts:      types.Int64Value(2),
+				InitialBackoff:   types.StringNull(),
+				MaxBackoff:       types.StringValue("later"),
+				RetryOnExitCodes: types.ListNull(types.Int64Type),
+			},
+			wantPath: pathPointer(path.Root("retry_policy").AtName("max_backoff")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := tt.model.toAPI(ctx)
+			if tt.wantPath != nil {
+				if len(diags) != 1 {
+					t.Fatalf("toAPI() = %v, want one error", diags)
+				}
+				if p := diagnosticPath(diags[0]); p == nil || !p.Equal(*tt.wantPath) {
+					t.Errorf("diagnostic path = %v, want %v", p, *tt.wantPath)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("toAPI() = %v", diags)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toAPI() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyFromAPI(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("single attempt without block", func(t *testing.T) {
+		got, diags := retryPolicyFromAPI(ctx, nil, &datahub.RetryPolicy{MaxAttempts: 1})
+		if diags.HasError() || got != nil {
+			t.Errorf("retryPolicyFromAPI() = %+v, %v, want no block", got, diags)
+		}
+	})
+
+	t.Run("keeps the notation of equal durations", func(t *testing.T) {
+		state := &retryPolicyModel{
+			MaxAttempts:    types.Int64Value(3),
+			InitialBackoff: types.StringValue("90s"),
+			MaxBackoff:     types.StringValue("1d"),
+		}
+		policy := &datahub.RetryPolicy{
+			MaxAttempts:      4,
+			InitialBackoff:   90 * time.Second,
+			MaxBackoff:       12 * time.Hour,
+			RetryOnExitCodes: []int{2},
+		}
+
+		got, diags := retryPolicyFromAPI(ctx, state, policy)
+		if diags.HasError() {
+			t.Fatalf("retryPolicyFromAPI() = %v", diags)
+		}
+		wantCodes, _ := types.ListValueFrom(ctx, types.Int64Type, []int{2})
+		want := &retryPolicyModel{
+			MaxAttempts:      types.Int64Value(4),
+			InitialBackoff:   types.StringValue("90s"),
+			MaxBackoff:       types.StringValue("12h0m0s"),
+			RetryOnExitCodes: wantCodes,
+		}
+		if !got.Equal(want) {
+			t.Errorf("retryPolicyFromAPI() = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestValidateRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		initialBackoff string
+		maxBackoff     string
+		wantErr        bool
+	}{
+		{"initial below max", "30s", "5m", false},
+		{"equal in other notation", "24h", "1d", false},
+		{"initial above max", "1h", "10m", true},
+		{"without max", "1h", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]tftypes.Value{
+				"max_attempts":    tftypes.NewValue(tftypes.Number, 3),
+				"initial_backoff": tftypes.NewValue(tftypes.String, tt.initialBackoff),
+			}
+			if tt.maxBackoff != "" {
+				values["max_backoff"] = tftypes.NewValue(tftypes.String, tt.maxBackoff)
+			}
+			config := testNestedConfig(t, "retry_policy", retryPolicyAttributes(), values)
+
+			var diags diag.Diagnostics
+			validateRetryPolicy(context.Background(), config, &diags)
+
+			if diags.HasError() != tt.wantErr {
+				t.Errorf("validateRetryPolicy() = %v, want error %t", diags, tt.wantErr)
+			}
+			if tt.wantErr {
+				if p := diagnosticPath(diags[0]); p == nil || !p.Equal(path.Root("retry_policy").AtName("initial_backoff")) {
+					t.Errorf("diagnostic path = %v, want retry_policy.initial_backoff", p)
+				}
+			}
+		})
+	}
+}
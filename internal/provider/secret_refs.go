@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// SecretRefSourceVault reads the secret from a HashiCorp Vault KV engine.
+	SecretRefSourceVault = "vault"
+	// SecretRefSourceAzureKeyVault reads the secret from an Azure Key Vault.
+	SecretRefSourceAzureKeyVault = "azure_key_vault"
+	// SecretRefSourceAWSSecretsManager reads the secret from AWS Secrets Manager.
+	SecretRefSourceAWSSecretsManager = "aws_secrets_manager"
+)
+
+var (
+	// vaultSecretPathRegexp matches Vault paths like kv/data/exact#token, the
+	// field after # is required as a Vault secret holds several values.
+	vaultSecretPathRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+(?:/[A-Za-z0-9_.-]+)+#[A-Za-z0-9_.-]+$`)
+
+	// azureKeyVaultSecretPathRegexp matches Azure Key Vault paths like
+	// my-vault/exact-token or my-vault/exact-token/<version>.
+	azureKeyVaultSecretPathRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]{1,22}[A-Za-z0-9]/[A-Za-z0-9-]{1,127}(?:/[0-9a-f]{32})?$`)
+
+	// awsSecretPathRegexp matches AWS Secrets Manager names or ARNs with an
+	// optional JSON key, like prod/exact#token.
+	awsSecretPathRegexp = regexp.MustCompile(`^[A-Za-z0-9/_+=.@:-]+(?:#[A-Za-z0-9_.-]+)?$`)
+
+	// secretRefPathFormats describes the path format per source for error messages.
+	secretRefPathFormats = map[string]string{
+		SecretRefSourceVault:             "a Vault path with the field to read, like kv/data/exact#token",
+		SecretRefSourceAzureKeyVault:     "the vault and secret name with an optional version, like my-vault/exact-token",
+		SecretRefSourceAWSSecretsManager: "a secret name or ARN with an optional JSON key, like prod/exact#token",
+	}
+)
+
+// secretRefsAttribute returns the secret_refs attribute of datahub_job.
+func secretRefsAttribute() schema.MapNestedAttribute {
+	return schema.MapNestedAttribute{
+		Description: "Secrets by environment variable name that Datahub reads from an external secret store at run time, so their values never pass through Terraform.",
+		Optional:    true,
+		Validators: []validator.Map{
+			mapvalidator.KeysAre(envVarNameValidator{}),
+		},
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"source": schema.StringAttribute{
+					Description: "secret store to read from: vault, azure_key_vault or aws_secrets_manager",
+					Required:    true,
+					Validators: []validator.String{
+						stringvalidator.OneOf(
+							SecretRefSourceVault,
+							SecretRefSourceAzureKeyVault,
+							SecretRefSourceAWSSecretsManager,
+						),
+					},
+				},
+				"path": schema.StringAttribute{
+					Description: "location of the secret in the store, like kv/data/exact#token for vault, my-vault/exact-token for azure_key_vault or prod/exact#token for aws_secrets_manager",
+					Required:    true,
+				},
+			},
+		},
+	}
+}
+
+type secretRefModel struct {
+	Source types.String `tfsdk:"source"`
+	Path   types.String `tfsdk:"path"`
+}
+
+// secretRefsEqual reports whether both maps hold the same references.
+func secretRefsEqual(a, b map[string]secretRefModel) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for key, refA := range a {
+		refB, ok := b[key]
+		if !ok || !refA.Source.Equal(refB.Source) || !refA.Path.Equal(refB.Path) {
+			return false
+		}
+	}
+	return true
+}
+
+// secretRefsToAPI converts the secret_refs attribute to its API representation.
+func secretRefsToAPI(refs map[string]secretRefModel) map[string]datahub.SecretRef {
+	secretRefs := map[string]datahub.SecretRef{}
+	for key, ref := range refs {
+		secretRefs[key] = datahub.SecretRef{
+			Source: ref.Source.ValueString(),
+			Path:   ref.Path.ValueString(),
+		}
+	}
+	return secretRefs
+}
+
+// secretRefsFromAPI converts the secret references returned by the API to
+// the secret_refs attribute.
+func secretRefsFromAPI(refs map[string]datahub.SecretRef) map[string]secretRefModel {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	secretRefs := map[string]secretRefModel{}
+	for key, ref := range refs {
+		secretRefs[key] = secretRefModel{
+			Source: types.StringValue(ref.Source),
+			Path:   types.StringValue(ref.Path),
+		}
+	}
+	return secretRefs
+}
+
+// validateSecretRefs checks that the path of every reference matches the
+// format of its source.
+func validateSecretRefs(ctx context.Context, config tfsdk.Config, diags *diag.Diagnostics) {
+	var secretRefs types.Map
+	diags.Append(config.GetAttribute(ctx, path.Root("secret_refs"), &secretRefs)...)
+	if diags.HasError() || secretRefs.IsNull() || secretRefs.IsUnknown() {
+		return
+	}
+
+	refs := map[string]secretRefModel{}
+	diags.Append(secretRefs.ElementsAs(ctx, &refs, false)...)
+	if diags.HasError() {
+		return
+	}
+
+	for _, key := range sortedKeys(refs) {
+		ref := refs[key]
+		if ref.Source.IsNull() || ref.Source.IsUnknown() || ref.Path.IsNull() || ref.Path.IsUnknown() {
+			continue
+		}
+
+		var pathRegexp *regexp.Regexp
+		switch ref.Source.ValueString() {
+		case SecretRefSourceVault:
+			pathRegexp = vaultSecretPathRegexp
+		case SecretRefSourceAzureKeyVault:
+			pathRegexp = azureKeyVaultSecretPathRegexp
+		case SecretRefSourceAWSSecretsManager:
+			pathRegexp = awsSecretPathRegexp
+		default:
+			// Reported by the validator of source
+			continue
+		}
+
+		value := ref.Path.ValueString()
+		if !pathRegexp.MatchString(value) || strings.Contains(value, "//") {
+			diags.AddAttributeError(
+				path.Root("secret_refs").AtMapKey(key).AtName("path"),
+				"Invalid Secret Reference",
+				"The path of a "+ref.Source.ValueString()+" secret reference must be "+secretRefPathFormats[ref.Source.ValueString()]+", got "+value+".",
+			)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testSecretRefsConfig returns the config of a secret_refs attribute holding
+// the references, each a source and a path.
+func testSecretRefsConfig(t *testing.T, refs map[string][2]string) tfsdk.Config {
+	t.Helper()
+
+	ctx := context.Background()
+	s := schema.Schema{Attributes: map[string]schema.Attribute{"secret_refs": secretRefsAttribute()}}
+	objectType := s.Type().TerraformType(ctx).(tftypes.Object)
+	mapType := objectType.AttributeTypes["secret_refs"].(tftypes.Map)
+	refType := mapType.ElementType.(tftypes.Object)
+
+	elements := map[string]tftypes.Value{}
+	for key, ref := range refs {
+		elements[key] = tftypes.NewValue(refType, map[string]tftypes.Value{
+			"source": tftypes.NewValue(tftypes.String, ref[0]),
+			"path":   tftypes.NewValue(tftypes.String, ref[1]),
+		})
+	}
+
+	return tfsdk.Config{
+		Schema: s,
+		Raw:    tftypes.NewValue(objectType, map[string]tftypes.Value{"secret_refs": tftypes.NewValue(mapType, elements)}),
+	}
+}
+
+func TestValidateSecretRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		path    string
+		wantErr bool
+	}{
+		{"vault", SecretRefSourceVault, "kv/data/exact#token", false},
+		{"vault without field", SecretRefSourceVault, "kv/data/exact", true},
+		{"vault without mount", SecretRefSourceVault, "exact#token", true},
+		{"vault with empty segment", SecretRefSourceVault, "kv//exact#token", true},
+		{"azure key vault", SecretRefSourceAzureKeyVault, "my-vault/exact-token", false},
+		{"azure key vault with version", SecretRefSourceAzureKeyVault, "my-vault/exact-token/0123456789abcdef0123456789abcdef", false},
+		{"azure key vault name too short", SecretRefSourceAzureKeyVault, "kv/exact-token", true},
+		{"azure key vault with field", SecretRefSourceAzureKeyVault, "my-vault/exact-token#field", true},
+		{"aws secrets manager", SecretRefSourceAWSSecretsManager, "prod/exact#token", false},
+		{"aws secrets manager ARN", SecretRefSourceAWSSecretsManager, "arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/exact-AbCdEf", false},
+		{"aws secrets manager with space", SecretRefSourceAWSSecretsManager, "prod/exact token", true},
+		{"unknown source is left to its validator", "gcp", "anything", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testSecretRefsConfig(t, map[string][2]string{"EXACT_TOKEN": {tt.source, tt.path}})
+
+			var diags diag.Diagnostics
+			validateSecretRefs(context.Background(), config, &diags)
+
+			if diags.HasError() != tt.wantErr {
+				t.Fatalf("validateSecretRefs() = %v, want error %t", diags, tt.wantErr)
+			}
+			if tt.wantErr {
+				want := path.Root("secret_refs").AtMapKey("EXACT_TOKEN").AtName("path")
+				if got := diagnosticPath(diags[0]); got == nil || !got.Equal(want) {
+					t.Errorf("diagnostic path = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretRefsAPIRoundTrip(t *testing.T) {
+	refs := map[string]secretRefModel{
+		"EXACT_TOKEN": {Source: types.StringValue(SecretRefSourceVault), Path: types.StringValue("kv/data/exact#token")},
+		"DB_PASSWORD": {Source: types.StringValue(SecretRefSourceAWSSecretsManager), Path: types.StringValue("prod/db")},
+	}
+
+	apiRefs := secretRefsToAPI(refs)
+	want := map[string]datahub.SecretRef{
+		"EXACT_TOKEN": {Source: "vault", Path: "kv/data/exact#token"},
+		"DB_PASSWORD": {Source: "aws_secrets_manager", Path: "prod/db"},
+	}
+	if !reflect.DeepEqual(apiRefs, want) {
+		t.Errorf("secretRefsToAPI() = %v, want %v", apiRefs, want)
+	}
+
+	if got := secretRefsFromAPI(apiRefs); !secretRefsEqual(got, refs) {
+		t.Errorf("secretRefsFromAPI() = %v, want %v", got, refs)
+	}
+	if got := secretRefsFromAPI(nil); got != nil {
+		t.Errorf("secretRefsFromAPI(nil) = %v, want nil", got)
+	}
+}
+
+func TestSecretRefsEqual(t *testing.T) {
+	ref := secretRefModel{Source: types.StringValue(SecretRefSourceVault), Path: types.StringValue("kv/data/exact#token")}
+	otherPath := secretRefModel{Source: types.StringValue(SecretRefSourceVault), Path: types.StringValue("kv/data/exact#other")}
+
+	tests := []struct {
+		name string
+		a, b map[string]secretRefModel
+		want bool
+	}{
+		{"both empty", nil, map[string]secretRefModel{}, true},
+		{"same", map[string]secretRefModel{"A": ref}, map[string]secretRefModel{"A": ref}, true},
+		{"other path", map[string]secretRefModel{"A": ref}, map[string]secretRefModel{"A": otherPath}, false},
+		{"other key", map[string]secretRefModel{"A": ref}, map[string]secretRefModel{"B": ref}, false},
+		{"extra key", map[string]secretRefModel{"A": ref}, map[string]secretRefModel{"A": ref, "B": ref}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := secretRefsEqual(tt.a, tt.b); got != tt.want {
+				t.Errorf("secretRefsEqual() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans of the provider.
+const tracerName = "terraform-provider-datahub"
+
+// Span attributes of provider operations.
+const (
+	resourceTypeKey = attribute.Key("terraform.resource_type")
+	operationKey    = attribute.Key("terraform.operation")
+	jobIDKey        = attribute.Key("datahub.job_id")
+)
+
+// parentSpanContext is the span of TRACEPARENT, the parent of the spans that
+// have no other parent.
+var parentSpanContext trace.SpanContext
+
+// InitTracing exports the spans of the provider via OTLP when
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set, and
+// leaves tracing disabled otherwise. The exporter is configured by the
+// standard OTEL_EXPORTER_OTLP_* environment variables. When TRACEPARENT is
+// set, like by the pipeline running Terraform, the spans become part of its
+// trace.
+//
+// The returned function flushes the spans that haven't been exported yet and
+// must be called before the provider exits.
+func InitTracing(ctx context.Context, version string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := sdkresource.Merge(
+		sdkresource.Default(),
+		sdkresource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(tracerName),
+			semconv.ServiceVersion(version),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+
+	if traceparent := os.Getenv("TRACEPARENT"); traceparent != "" {
+		parentCtx := otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier{"traceparent": traceparent})
+		parentSpanContext = trace.SpanContextFromContext(parentCtx)
+	}
+
+	return tracerProvider.Shutdown, nil
+}
+
+// startSpan starts the span of an operation on a resource or data source type,
+// like Create of datahub_job. End it with endSpan.
+func startSpan(ctx context.Context, resourceType string, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	attributes = append(attributes, resourceTypeKey.String(resourceType), operationKey.String(operation))
+	if !trace.SpanContextFromContext(ctx).IsValid() && parentSpanContext.IsValid() {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, parentSpanContext)
+	}
+	return otel.Tracer(tracerName).Start(ctx, resourceType+"."+operation, trace.WithAttributes(attributes...))
+}
+
+// endSpan ends the span, marking it as failed when diags has errors. Pass the
+// diagnostics of the response so errors added later are taken into account:
+//
+//	ctx, span := startSpan(ctx, "datahub_job", "Create")
+//	defer endSpan(span, &resp.Diagnostics)
+func endSpan(span trace.Span, diags *diag.Diagnostics) {
+	if diags.HasError() {
+		var summaries []string
+		for _, d := range diags.Errors() {
+			summaries = append(summaries, d.Summary())
+		}
+		span.SetStatus(codes.Error, strings.Join(summaries, "; "))
+	}
+	span.End()
+}
+
+// setSpanAttributes adds attributes, like the job ID once it is known, to the
+// span of the operation.
+func setSpanAttributes(ctx context.Context, attributes ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attributes...)
+}
+
+// spanTransport records a client span for every request to the Datahub API
+// and propagates the trace to it.
+type spanTransport struct {
+	base http.RoundTripper
+}
+
+func (t *spanTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := otel.Tracer(tracerName).Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLFull(redactURL(req.URL)),
+			semconv.ServerAddress(req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// recordSpans exports the spans of the test to an in-memory exporter, with
+// the trace propagated like after InitTracing.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+		_ = tracerProvider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+// spanAttribute returns the value of the attribute of the span, if set.
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestOperationSpans(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		diagnostic     bool
+		wantStatus     codes.Code
+		wantHTTPStatus codes.Code
+	}{
+		{
+			name:           "success",
+			status:         http.StatusOK,
+			wantStatus:     codes.Unset,
+			wantHTTPStatus: codes.Unset,
+		},
+		{
+			name:           "error diagnostic",
+			status:         http.StatusNotFound,
+			diagnostic:     true,
+			wantStatus:     codes.Error,
+			wantHTTPStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := recordSpans(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Traceparent") == "" {
+					t.Error("request has no traceparent header")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(server.Close)
+
+			var diags diag.Diagnostics
+			ctx, span := startSpan(context.Background(), "datahub_job", "Read")
+			setSpanAttributes(ctx, jobIDKey.String("42"))
+
+			client := &http.Client{Transport: &spanTransport{base: http.DefaultTransport}}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/jobs/42", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if tt.diagnostic {
+				diags.AddError("Error Reading Datahub Job", "job 42 not found")
+			}
+			endSpan(span, &diags)
+
+			spans := exporter.GetSpans()
+			if len(spans) != 2 {
+				t.Fatalf("recorded %d spans, want 2", len(spans))
+			}
+			httpSpan, operationSpan := spans[0], spans[1]
+
+			if operationSpan.Name != "datahub_job.Read" {
+				t.Errorf("operation span name = %q, want %q", operationSpan.Name, "datahub_job.Read")
+			}
+			for key, want := range map[attribute.Key]string{
+				resourceTypeKey: "datahub_job",
+				operationKey:    "Read",
+				jobIDKey:        "42",
+			} {
+				if got, _ := spanAttribute(operationSpan, key); got.AsString() != want {
+					t.Errorf("operation span %s = %q, want %q", key, got.AsString(), want)
+				}
+			}
+			if operationSpan.Status.Code != tt.wantStatus {
+				t.Errorf("operation span status = %v, want %v", operationSpan.Status.Code, tt.wantStatus)
+			}
+			if tt.diagnostic && operationSpan.Status.Description != "Error Reading Datahub Job" {
+				t.Errorf("operation span status description = %q, want the diagnostic summary", operationSpan.Status.Description)
+			}
+
+			if httpSpan.Name != "HTTP GET" {
+				t.Errorf("HTTP span name = %q, want %q", httpSpan.Name, "HTTP GET")
+			}
+			if httpSpan.Parent.SpanID() != operationSpan.SpanContext.SpanID() {
+				t.Error("HTTP span is not a child of the operation span")
+			}
+			if got, _ := spanAttribute(httpSpan, semconv.HTTPResponseStatusCodeKey); got.AsInt64() != int64(tt.status) {
+				t.Errorf("HTTP span %s = %d, want %d", semconv.HTTPResponseStatusCodeKey, got.AsInt64(), tt.status)
+			}
+			if httpSpan.Status.Code != tt.wantHTTPStatus {
+				t.Errorf("HTTP span status = %v, want %v", httpSpan.Status.Code, tt.wantHTTPStatus)
+			}
+		})
+	}
+}
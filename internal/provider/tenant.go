@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"dev.azure.com/AllYourBI/Datahub/_git/go-datahub-sdk.git/pkg/datahub"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// actAsClientIDAttribute is the act_as_client_id attribute of resources that
+// can be managed on behalf of another Datahub client, like a datahub_client
+// of a customer. The object belongs to that client, so changing it replaces
+// the object.
+func actAsClientIDAttribute(object string) schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: "client_id of the Datahub client to manage the " + object + " as, instead of the client of the provider. " +
+			"The client of the provider needs the permission to act as it.",
+		Optional: true,
+		Validators: []validator.String{
+			uuidValidator(),
+		},
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.RequiresReplace(),
+		},
+	}
+}
+
+// tenantClients hands out the Datahub client of the provider, or the client
+// acting as another Datahub client for resources with act_as_client_id. A
+// client is created once per tenant and shared by all resources, so one
+// provider manages many tenants.
+type tenantClients struct {
+	// client is the client of the provider itself.
+	client *datahub.DatahubClient
+
+	// newTenantClient creates the client acting as the tenant.
+	newTenantClient func(actAsClientID string) (*datahub.DatahubClient, error)
+
+	mu      sync.Mutex
+	tenants map[string]*datahub.DatahubClient
+}
+
+// get returns the client for actAsClientID, the client of the provider when
+// it is null or empty.
+func (t *tenantClients) get(actAsClientID types.String) (*datahub.DatahubClient, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if actAsClientID.IsNull() || actAsClientID.IsUnknown() || actAsClientID.ValueString() == "" {
+		return t.client, diags
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	clientID := actAsClientID.ValueString()
+	if client, ok := t.tenants[clientID]; ok {
+		return client, diags
+	}
+
+	client, err := t.newTenantClient(clientID)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("act_as_client_id"),
+			"Unable to Create Datahub API Client",
+			"Could not create the client acting as Datahub client "+clientID+": "+err.Error(),
+		)
+		return nil, diags
+	}
+
+	if t.tenants == nil {
+		t.tenants = map[string]*datahub.DatahubClient{}
+	}
+	t.tenants[clientID] = client
+	return client, diags
+}
+
+// impersonationTokenSource gets access tokens acting as another Datahub
+// client, like a customer client created by the provider, with an RFC 8693
+// token exchange. The access token of the provider is the subject_token, and
+// the client to act as is the audience of the requested token.
+type impersonationTokenSource struct {
+	httpClient *http.Client
+
+	// tokenURL is the Datahub token endpoint.
+	tokenURL string
+
+	// actAsClientID is the client the tokens act as.
+	actAsClientID string
+
+	// subject gets the access tokens of the provider itself.
+	subject datahub.TokenSource
+
+	mu      sync.Mutex
+	current grantedToken
+}
+
+// Token returns a valid access token acting as the tenant, exchanging a new
+// one when the cached one is about to expire.
+func (s *impersonationTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current.fresh() {
+		return s.current.value, nil
+	}
+
+	subjectToken, err := s.subject.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("requesting access token of the provider: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type":           {tokenExchangeGrantType},
+		"subject_token":        {subjectToken},
+		"subject_token_type":   {accessTokenType},
+		"requested_token_type": {accessTokenType},
+		"audience":             {s.actAsClientID},
+	}
+	token, err := requestAccessToken(ctx, s.httpClient, s.tokenURL, form)
+	if err != nil {
+		return "", err
+	}
+
+	s.current = token
+	return s.current.value, nil
+}
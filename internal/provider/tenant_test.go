@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// testSubjectTokenSource returns a fixed access token of the provider.
+type testSubjectTokenSource struct {
+	token string
+	err   error
+}
+
+func (s testSubjectTokenSource) Token(context.Context) (string, error) {
+	return s.token, s.err
+}
+
+func TestImpersonationTokenSourceExchangeForm(t *testing.T) {
+	endpoint := &testTokenEndpoint{expiresIn: 3600}
+	server := endpoint.serve(t)
+
+	source := &impersonationTokenSource{
+		httpClient:    server.Client(),
+		tokenURL:      server.URL + tokenExchangePath,
+		actAsClientID: "customer",
+		subject:       testSubjectTokenSource{token: "provider-token"},
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		if token != "access-1" {
+			t.Errorf("Token() = %q, want access-1", token)
+		}
+	}
+
+	if len(endpoint.forms) != 1 {
+		t.Fatalf("token endpoint called %d times, want the token cached", len(endpoint.forms))
+	}
+	form := endpoint.forms[0]
+	want := map[string]string{
+		"grant_type":           tokenExchangeGrantType,
+		"subject_token":        "provider-token",
+		"subject_token_type":   accessTokenType,
+		"requested_token_type": accessTokenType,
+		"audience":             "customer",
+	}
+	for key, value := range want {
+		if got := form.Get(key); got != value {
+			t.Errorf("form %s = %q, want %q", key, got, value)
+		}
+	}
+	for _, key := range []string{"client_secret", "requested_subject"} {
+		if form.Has(key) {
+			t.Errorf("form has %s, want only the parameters of RFC 8693", key)
+		}
+	}
+}
+
+func TestImpersonationTokenSourceRefreshes(t *testing.T) {
+	// The token expires within tokenRefreshMargin, so every call exchanges
+	// the then current token of the provider again
+	endpoint := &testTokenEndpoint{expiresIn: 1}
+	server := endpoint.serve(t)
+
+	source := &impersonationTokenSource{
+		httpClient:    server.Client(),
+		tokenURL:      server.URL + tokenExchangePath,
+		actAsClientID: "customer",
+		subject:       testSubjectTokenSource{token: "provider-token"},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := source.Token(context.Background()); err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+	}
+	if got := endpoint.subjectTokens(); len(got) != 2 {
+		t.Errorf("subject tokens = %v, want two exchanges", got)
+	}
+}
+
+func TestImpersonationTokenSourceErrors(t *testing.T) {
+	subjectErr := errors.New("invalid_client")
+
+	tests := []struct {
+		name      string
+		status    int
+		subject   testSubjectTokenSource
+		wantCalls int
+		wantErr   func(error) bool
+	}{
+		{
+			name:      "provider token fails",
+			subject:   testSubjectTokenSource{err: subjectErr},
+			wantCalls: 0,
+			wantErr:   func(err error) bool { return errors.Is(err, subjectErr) },
+		},
+		{
+			name:      "exchange rejected",
+			status:    http.StatusForbidden,
+			subject:   testSubjectTokenSource{token: "provider-token"},
+			wantCalls: 1,
+			wantErr: func(err error) bool {
+				var apiErr *apiError
+				return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := &testTokenEndpoint{expiresIn: 3600, status: tt.status}
+			server := endpoint.serve(t)
+
+			source := &impersonationTokenSource{
+				httpClient:    server.Client(),
+				tokenURL:      server.URL + tokenExchangePath,
+				actAsClientID: "customer",
+				subject:       tt.subject,
+			}
+
+			_, err := source.Token(context.Background())
+			if err == nil || !tt.wantErr(err) {
+				t.Errorf("Token() error = %v, want the cause", err)
+			}
+			if len(endpoint.forms) != tt.wantCalls {
+				t.Errorf("token endpoint called %d times, want %d", len(endpoint.forms), tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+var (
+	// quantityRegexp matches Kubernetes style resource quantities like 500m,
+	// 1.5, 512Mi or 1e3, capturing the number and the suffix.
+	quantityRegexp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]*)?|\.[0-9]+)([KMGTPE]i|[numkMGTPE]|[eE][+-]?[0-9]+)?$`)
+
+	// quantitySuffixes maps the binary and decimal quantity suffixes to their multiplier.
+	quantitySuffixes = map[string]float64{
+		"n": 1e-9, "u": 1e-6, "m": 1e-3, "": 1,
+		"k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18,
+		"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40, "Pi": 1 << 50, "Ei": 1 << 60,
+	}
+
+	// envVarNameRegexp matches POSIX environment variable names.
+	envVarNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// imageReferenceRegexp matches container image references like
+	// registry.example.com:5000/team/image:tag@sha256:..., following the
+	// grammar of the distribution reference package.
+	imageReferenceRegexp = func() *regexp.Regexp {
+		domainComponent := `(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])`
+		domain := domainComponent + `(?:\.` + domainComponent + `)*(?::[0-9]+)?`
+		pathComponent := `[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*`
+		name := `(?:` + domain + `/)?` + pathComponent + `(?:/` + pathComponent + `)*`
+		tag := `:[\w][\w.-]{0,127}`
+		digest := `@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}`
+		return regexp.MustCompile(`^` + name + `(?:` + tag + `)?(?:` + digest + `)?$`)
+	}()
+
+	// uuidRegexp matches UUIDs in their canonical form.
+	uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+	// cronMacros are the predefined schedules accepted instead of five fields.
+	cronMacros = []string{"@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly"}
+
+	// cronRegexp matches a cron expression of five fields, each like *, */15,
+	// 1-5, MON-FRI or 0,30, or one of cronMacros.
+	cronRegexp = func() *regexp.Regexp {
+		item := `(?:\*|[0-9A-Za-z]+(?:-[0-9A-Za-z]+)?)(?:/[0-9]+)?`
+		field := item + `(?:,` + item + `)*`
+		macros := strings.Join(cronMacros, "|")
+		return regexp.MustCompile(`^(?:` + macros + `|` + field + `(?:\s+` + field + `){4})$`)
+	}()
+)
+
+// rfc3339Validator checks that a string attribute holds an RFC3339 timestamp.
+type rfc3339Validator struct{}
+
+func (v rfc3339Validator) Description(_ context.Context) string {
+	return "value must be an RFC3339 timestamp like 2025-01-31T00:00:00Z"
+}
+
+func (v rfc3339Validator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v rfc3339Validator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := time.Parse(time.RFC3339, req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Timestamp",
+			fmt.Sprintf("The %s %s, got %q: %s", req.Path, v.Description(ctx), req.ConfigValue.ValueString(), err),
+		)
+	}
+}
+
+// durationValidator checks that a string attribute holds a positive duration
+// as accepted by parseDuration.
+type durationValidator struct{}
+
+func (v durationValidator) Description(_ context.Context) string {
+	return "value must be a positive duration like 720h or 90d"
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	d, err := parseDuration(req.ConfigValue.ValueString())
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("duration must be greater than zero")
+	}
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Duration",
+			fmt.Sprintf("The %s %s, got %q: %s", req.Path, v.Description(ctx), req.ConfigValue.ValueString(), err),
+		)
+	}
+}
+
+// parseDuration parses a Go duration string, additionally accepting a whole
+// number of days like "90d".
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+// imageReferenceValidator checks that a string attribute holds a valid
+// container image reference.
+type imageReferenceValidator struct{}
+
+func (v imageReferenceValidator) Description(_ context.Context) string {
+	return "value must be a container image reference like registry.example.com/team/image:tag"
+}
+
+func (v imageReferenceValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v imageReferenceValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if !imageReferenceRegexp.MatchString(req.ConfigValue.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Image Reference",
+			fmt.Sprintf("The %s %s, got %q.", req.Path, v.Description(ctx), req.ConfigValue.ValueString()),
+		)
+	}
+}
+
+// httpURLValidator checks that a string attribute holds an absolute http or
+// https URL.
+type httpURLValidator struct{}
+
+func (v httpURLValidator) Description(_ context.Context) string {
+	return "value must be an absolute http or https URL"
+}
+
+func (v httpURLValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v httpURLValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if err := checkHTTPURL(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid URL",
+			fmt.Sprintf("The %s %s, got %q: %s", req.Path, v.Description(ctx), req.ConfigValue.ValueString(), err),
+		)
+	}
+}
+
+// checkHTTPURL returns an error when value isn't an absolute http or https URL.
+func checkHTTPURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("missing http(s) scheme or host")
+	}
+	return nil
+}
+
+// envVarNameValidator checks that a string attribute holds a valid POSIX
+// environment variable name or prefix.
+type envVarNameValidator struct{}
+
+func (v envVarNameValidator) Description(_ context.Context) string {
+	return "value must consist of letters, digits and underscores and must not start with a digit"
+}
+
+func (v envVarNameValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v envVarNameValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if !envVarNameRegexp.MatchString(req.ConfigValue.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Environment Variable Name",
+			fmt.Sprintf("The %s %s, got %q.", req.Path, v.Description(ctx), req.ConfigValue.ValueString()),
+		)
+	}
+}
+
+// quantityValidator checks that a string attribute holds a Kubernetes style
+// resource quantity.
+type quantityValidator struct{}
+
+func (v quantityValidator) Description(_ context.Context) string {
+	return "value must be a resource quantity like 500m, 2, 512Mi or 1Gi"
+}
+
+func (v quantityValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v quantityValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := parseQuantity(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Resource Quantity",
+			fmt.Sprintf("The %s %s, got %q.", req.Path, v.Description(ctx), req.ConfigValue.ValueString()),
+		)
+	}
+}
+
+// parseQuantity parses a Kubernetes style resource quantity into its value.
+func parseQuantity(s string) (float64, error) {
+	match := quantityRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+
+	multiplier, ok := quantitySuffixes[match[2]]
+	if !ok {
+		// Decimal exponent like 1e3
+		return strconv.ParseFloat(s, 64)
+	}
+
+	n, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return n * multiplier, nil
+}
+
+// uuidValidator checks that a string attribute holds a UUID.
+func uuidValidator() validator.String {
+	return stringvalidator.RegexMatches(uuidRegexp, "value must be a UUID")
+}
+
+// cronValidator checks that a string attribute holds a cron expression with
+// five fields or one of the predefined schedules like @daily.
+func cronValidator() validator.String {
+	return stringvalidator.RegexMatches(cronRegexp,
+		"value must be a cron expression with five fields like 0 6 * * MON-FRI, or one of "+strings.Join(cronMacros, ", "))
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestStringValidators(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator validator.String
+		value     string
+		wantErr   bool
+	}{
+		{"quantity millicores", quantityValidator{}, "500m", false},
+		{"quantity binary suffix", quantityValidator{}, "1.5Gi", false},
+		{"quantity exponent", quantityValidator{}, "1e3", false},
+		{"quantity unknown suffix", quantityValidator{}, "512MB", true},
+		{"quantity negative", quantityValidator{}, "-1", true},
+		{"env var name", envVarNameValidator{}, "DB_HOST_2", false},
+		{"env var name with underscore", envVarNameValidator{}, "_PRIVATE", false},
+		{"env var name starting with digit", envVarNameValidator{}, "2FA", true},
+		{"env var name with dash", envVarNameValidator{}, "DB-HOST", true},
+		{"duration", durationValidator{}, "1h30m", false},
+		{"duration in days", durationValidator{}, "90d", false},
+		{"duration zero", durationValidator{}, "0s", true},
+		{"duration without unit", durationValidator{}, "90", true},
+		{"image reference", imageReferenceValidator{}, "registry.example.com:5000/team/image:1.0", false},
+		{"image reference with digest", imageReferenceValidator{}, "alpine@sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", false},
+		{"image reference with upper case path", imageReferenceValidator{}, "registry.example.com/Team/image", true},
+		{"http URL", httpURLValidator{}, "https://api.example.com/v1", false},
+		{"URL without scheme", httpURLValidator{}, "api.example.com", true},
+		{"URL with other scheme", httpURLValidator{}, "ftp://example.com", true},
+		{"RFC3339 timestamp", rfc3339Validator{}, "2025-01-31T00:00:00Z", false},
+		{"date only", rfc3339Validator{}, "2025-01-31", true},
+		{"UUID", uuidValidator(), "0b3c1f3e-8a6f-4b9e-9d2a-1c2b3d4e5f60", false},
+		{"UUID without dashes", uuidValidator(), "0b3c1f3e8a6f4b9e9d2a1c2b3d4e5f60", true},
+		{"cron", cronValidator(), "0 6 * * MON-FRI", false},
+		{"cron with steps and lists", cronValidator(), "*/15 0,12 1-5 * *", false},
+		{"cron macro", cronValidator(), "@daily", false},
+		{"cron with six fields", cronValidator(), "0 0 6 * * MON", true},
+		{"cron unknown macro", cronValidator(), "@sometimes", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validator.StringRequest{Path: path.Root("value"), ConfigValue: types.StringValue(tt.value)}
+			resp := &validator.StringResponse{}
+			tt.validator.ValidateString(context.Background(), req, resp)
+
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Errorf("ValidateString(%q) = %v, want error %t", tt.value, resp.Diagnostics, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStringValidatorsSkipNullAndUnknown(t *testing.T) {
+	validators := []validator.String{
+		quantityValidator{}, envVarNameValidator{}, durationValidator{}, imageReferenceValidator{},
+		httpURLValidator{}, rfc3339Validator{}, uuidValidator(), cronValidator(),
+	}
+
+	for _, v := range validators {
+		for _, value := range []types.String{types.StringNull(), types.StringUnknown()} {
+			resp := &validator.StringResponse{}
+			v.ValidateString(context.Background(), validator.StringRequest{Path: path.Root("value"), ConfigValue: value}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Errorf("%s: ValidateString(%v) = %v, want no error", v.Description(context.Background()), value, resp.Diagnostics)
+			}
+		}
+	}
+}
+
+func TestEnvVarKeys(t *testing.T) {
+	value := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"DB_HOST": types.StringValue("db"),
+		"2FA":     types.StringValue("on"),
+	})
+
+	resp := &validator.MapResponse{}
+	mapvalidator.KeysAre(envVarNameValidator{}).ValidateMap(context.Background(), validator.MapRequest{Path: path.Root("environment"), ConfigValue: value}, resp)
+
+	if resp.Diagnostics.ErrorsCount() != 1 {
+		t.Fatalf("ValidateMap() = %v, want one error for 2FA", resp.Diagnostics)
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"500m", 0.5, false},
+		{"2", 2, false},
+		{".5", 0.5, false},
+		{"1k", 1000, false},
+		{"512Mi", 512 << 20, false},
+		{"2Gi", 2 << 30, false},
+		{"1e3", 1000, false},
+		{"5n", 5e-9, false},
+		{"", 0, true},
+		{"Gi", 0, true},
+		{"1.5.0", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseQuantity(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQuantity(%q) error = %v, want error %t", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseQuantity(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30m", 30 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"90d", 90 * 24 * time.Hour, false},
+		{"1.5d", 0, true},
+		{"d", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDuration(%q) error = %v, want error %t", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDuration(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// writeOnlySecretsAttributes returns the secrets_wo and secrets_wo_version
+// attributes shared by datahub_job and datahub_init_run. Changing the
+// version replaces the resource when requiresReplace is set.
+func writeOnlySecretsAttributes(requiresReplace bool) map[string]schema.Attribute {
+	versionPlanModifiers := []planmodifier.Int64{}
+	if requiresReplace {
+		versionPlanModifiers = append(versionPlanModifiers, int64planmodifier.RequiresReplace())
+	}
+
+	return map[string]schema.Attribute{
+		"secrets_wo": schema.MapAttribute{
+			Description: "Secrets that are sent to Datahub but never stored in the plan or state, requires Terraform 1.11 or later. " +
+				"Changes to the values are only sent when secrets_wo_version changes too.",
+			ElementType: types.StringType,
+			Optional:    true,
+			Sensitive:   true,
+			WriteOnly:   true,
+			Validators: []validator.Map{
+				mapvalidator.KeysAre(envVarNameValidator{}),
+			},
+		},
+		"secrets_wo_version": schema.Int64Attribute{
+			Description:   "Version of secrets_wo, change it to send the values of secrets_wo again.",
+			Optional:      true,
+			PlanModifiers: versionPlanModifiers,
+		},
+	}
+}
+
+// writeOnlySecrets returns the secrets_wo values from the configuration, as
+// they are never part of the plan.
+func writeOnlySecrets(ctx context.Context, config tfsdk.Config) (map[string]string, diag.Diagnostics) {
+	var secretsWO types.Map
+	diags := config.GetAttribute(ctx, path.Root("secrets_wo"), &secretsWO)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	secrets := map[string]string{}
+	diags.Append(secretsWO.ElementsAs(ctx, &secrets, false)...)
+	return secrets, diags
+}
+
+// mergeSecrets returns the secrets together with the write-only secrets.
+func mergeSecrets(secrets map[string]string, writeOnly map[string]string) map[string]string {
+	merged := map[string]string{}
+	for key, value := range secrets {
+		merged[key] = value
+	}
+	for key, value := range writeOnly {
+		merged[key] = value
+	}
+	return merged
+}
+
+// validateWriteOnlySecrets checks that the keys of secrets_wo aren't set in
+// the environment or secrets attributes as well.
+func validateWriteOnlySecrets(ctx context.Context, config tfsdk.Config, diags *diag.Diagnostics) {
+	var environment, secrets, secretsWO types.Map
+	diags.Append(config.GetAttribute(ctx, path.Root("environment"), &environment)...)
+	diags.Append(config.GetAttribute(ctx, path.Root("secrets"), &secrets)...)
+	diags.Append(config.GetAttribute(ctx, path.Root("secrets_wo"), &secretsWO)...)
+	if diags.HasError() {
+		return
+	}
+
+	for key := range secretsWO.Elements() {
+		for attribute, values := range map[string]types.Map{"environment": environment, "secrets": secrets} {
+			if _, found := values.Elements()[key]; found {
+				diags.AddAttributeError(
+					path.Root("secrets_wo").AtMapKey(key),
+					"Conflicting Environment Variable",
+					"The key "+key+" is set in both "+attribute+" and secrets_wo, it can only be set in one of them.",
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testSecretsConfig returns the config of the environment, secrets and
+// secrets_wo attributes, null when not given.
+func testSecretsConfig(t *testing.T, values map[string]map[string]string) tfsdk.Config {
+	t.Helper()
+
+	ctx := context.Background()
+	attributes := writeOnlySecretsAttributes(false)
+	attributes["environment"] = schema.MapAttribute{ElementType: types.StringType, Optional: true}
+	attributes["secrets"] = schema.MapAttribute{ElementType: types.StringType, Optional: true, Sensitive: true}
+	s := schema.Schema{Attributes: attributes}
+
+	objectType := s.Type().TerraformType(ctx).(tftypes.Object)
+	object := map[string]tftypes.Value{}
+	for name, typ := range objectType.AttributeTypes {
+		object[name] = tftypes.NewValue(typ, nil)
+		if elements, ok := values[name]; ok {
+			mapValue := map[string]tftypes.Value{}
+			for key, value := range elements {
+				mapValue[key] = tftypes.NewValue(tftypes.String, value)
+			}
+			object[name] = tftypes.NewValue(typ, mapValue)
+		}
+	}
+
+	return tfsdk.Config{Schema: s, Raw: tftypes.NewValue(objectType, object)}
+}
+
+func TestWriteOnlySecrets(t *testing.T) {
+	config := testSecretsConfig(t, map[string]map[string]string{
+		"secrets_wo": {"DB_PASSWORD": "hunter2"},
+	})
+
+	got, diags := writeOnlySecrets(context.Background(), config)
+	if diags.HasError() {
+		t.Fatalf("writeOnlySecrets() = %v", diags)
+	}
+	if want := map[string]string{"DB_PASSWORD": "hunter2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("writeOnlySecrets() = %v, want %v", got, want)
+	}
+
+	got, diags = writeOnlySecrets(context.Background(), testSecretsConfig(t, nil))
+	if diags.HasError() || len(got) != 0 {
+		t.Errorf("writeOnlySecrets() without secrets_wo = %v, %v, want none", got, diags)
+	}
+}
+
+func TestMergeSecrets(t *testing.T) {
+	secrets := map[string]string{"API_KEY": "k", "DB_PASSWORD": "old"}
+	got := mergeSecrets(secrets, map[string]string{"DB_PASSWORD": "hunter2"})
+
+	want := map[string]string{"API_KEY": "k", "DB_PASSWORD": "hunter2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeSecrets() = %v, want %v", got, want)
+	}
+	if secrets["DB_PASSWORD"] != "old" {
+		t.Errorf("mergeSecrets() changed its input to %v", secrets)
+	}
+}
+
+func TestValidateWriteOnlySecrets(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    map[string]map[string]string
+		wantPaths []path.Path
+	}{
+		{
+			name: "distinct keys",
+			values: map[string]map[string]string{
+				"environment": {"DB_HOST": "db"},
+				"secrets":     {"API_KEY": "k"},
+				"secrets_wo":  {"DB_PASSWORD": "hunter2"},
+			},
+		},
+		{
+			name: "key in secrets",
+			values: map[string]map[string]string{
+				"secrets":    {"DB_PASSWORD": "old"},
+				"secrets_wo": {"DB_PASSWORD": "hunter2"},
+			},
+			wantPaths: []path.Path{path.Root("secrets_wo").AtMapKey("DB_PASSWORD")},
+		},
+		{
+			name: "key in environment",
+			values: map[string]map[string]string{
+				"environment": {"DB_HOST": "db"},
+				"secrets_wo":  {"DB_HOST": "db"},
+			},
+			wantPaths: []path.Path{path.Root("secrets_wo").AtMapKey("DB_HOST")},
+		},
+		{
+			name:   "without secrets_wo",
+			values: map[string]map[string]string{"secrets": {"DB_PASSWORD": "old"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			validateWriteOnlySecrets(context.Background(), testSecretsConfig(t, tt.values), &diags)
+
+			if len(diags) != len(tt.wantPaths) {
+				t.Fatalf("validateWriteOnlySecrets() = %v, want %d errors", diags, len(tt.wantPaths))
+			}
+			for i, want := range tt.wantPaths {
+				if got := diagnosticPath(diags[i]); got == nil || !got.Equal(want) {
+					t.Errorf("diagnostic path = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteOnlySecretsVersionRequiresReplace(t *testing.T) {
+	// Only datahub_init_run replaces itself to send new values
+	for _, requiresReplace := range []bool{false, true} {
+		version := writeOnlySecretsAttributes(requiresReplace)["secrets_wo_version"].(schema.Int64Attribute)
+		if got := len(version.PlanModifiers) == 1; got != requiresReplace {
+			t.Errorf("writeOnlySecretsAttributes(%t) plan modifiers = %v", requiresReplace, version.PlanModifiers)
+		}
+	}
+}
+
+func TestResolveSecretsWOKeys(t *testing.T) {
+	ctx := context.Background()
+	known, _ := types.SetValueFrom(ctx, types.StringType, []string{"API_KEY"})
+
+	tests := []struct {
+		name      string
+		keys      types.Set
+		secretsWO map[string]string
+		want      []string
+		wantNull  bool
+	}{
+		{"unknown resolved from secrets_wo", types.SetUnknown(types.StringType), map[string]string{"B": "2", "A": "1"}, []string{"A", "B"}, false},
+		{"unknown without secrets_wo", types.SetUnknown(types.StringType), nil, nil, true},
+		{"known keys are kept", known, map[string]string{"OTHER": "1"}, []string{"API_KEY"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &jobResourceModel{SecretsWOKeys: tt.keys}
+			if diags := model.resolveSecretsWOKeys(ctx, tt.secretsWO); diags.HasError() {
+				t.Fatalf("resolveSecretsWOKeys() = %v", diags)
+			}
+
+			if tt.wantNull {
+				if !model.SecretsWOKeys.IsNull() {
+					t.Errorf("secrets_wo_keys = %v, want null", model.SecretsWOKeys)
+				}
+				return
+			}
+			var got []string
+			model.SecretsWOKeys.ElementsAs(ctx, &got, false)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("secrets_wo_keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepUntrackedSecrets(t *testing.T) {
+	ctx := context.Background()
+	secrets, _ := types.MapValueFrom(ctx, types.StringType, map[string]string{"API_KEY": "k"})
+	secretsWOKeys, _ := types.SetValueFrom(ctx, types.StringType, []string{"DB_PASSWORD"})
+
+	// Keys of secrets and secrets_wo are managed by the job, removing them
+	// deletes them, other keys, like those of datahub_job_secret, are kept
+	current := map[string]string{"API_KEY": "k", "DB_PASSWORD": "hunter2", "EXTERNAL": "x"}
+	got := keepUntracked(trackedKeys(secrets, secretsWOKeys), map[string]string{"API_KEY": "new"}, current)
+
+	want := map[string]string{"API_KEY": "new", "EXTERNAL": "x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("keepUntracked() = %v, want %v", got, want)
+	}
+}
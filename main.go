@@ -57,11 +57,16 @@ import (
 	"flag"
 	"log"
 	"terraform-provider-datahub/internal/provider"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 )
 
+// version is set by goreleaser, see .goreleaser.yml.
+var version string = "dev"
+
 // Provider documentation generation.
+//
 //go:generate go run github.com/hashicorp/terraform-plugin-docs/cmd/tfplugindocs generate --provider-name datahub
 func main() {
 
@@ -70,7 +75,12 @@ func main() {
 	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with support for debuggers like delve")
 	flag.Parse()
 
-	err := providerserver.Serve(context.Background(), provider.New, providerserver.ServeOpts{
+	shutdownTracing, err := provider.InitTracing(context.Background(), version)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	err = providerserver.Serve(context.Background(), provider.New(version), providerserver.ServeOpts{
 		// NOTE: This is not a typical Terraform Registry provider address,
 		// such as registry.terraform.io/hashicorp/hashicups. This specific
 		// provider address is used in these tutorials in conjunction with a
@@ -81,6 +91,13 @@ func main() {
 		// ProtocolVersion: 6,
 	})
 
+	// Export the spans that are left before Terraform stops the provider
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("exporting traces: %s", err)
+	}
+	cancel()
+
 	if err != nil {
 		log.Fatal(err.Error())
 	}